@@ -59,6 +59,11 @@
 // is launched, so to truly gate a group behind infrastructure that service must
 // report readiness. StartupTimeout bounds the wait for this signal.
 //
+// Instead of a priority, a service may declare the services it depends on with
+// [github.com/tkcrm/mx/launcher.WithDependsOn]. It starts once all of its
+// dependencies are ready and is stopped before any of them; unknown names and
+// cycles make Run fail before anything starts.
+//
 // Shutdown order is controlled independently via
 // [github.com/tkcrm/mx/launcher.WithRunnerServicesSequence] (None/Fifo/Lifo).
 //
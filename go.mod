@@ -4,8 +4,8 @@ go 1.26.0
 
 require (
 	github.com/goccy/go-json v0.10.6
+	github.com/prometheus/client_model v0.6.2
 	go.uber.org/zap v1.28.0
-	golang.org/x/sync v0.22.0
 )
 
 require (
@@ -14,8 +14,8 @@ require (
 	github.com/felixge/httpsnoop v1.1.0 // indirect
 	github.com/go-logr/logr v1.4.4 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
-	github.com/prometheus/client_model v0.6.2 // indirect
 	github.com/prometheus/common v0.70.0 // indirect
 	github.com/prometheus/procfs v0.21.1 // indirect
 	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
@@ -0,0 +1,71 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tkcrm/mx/launcher/ops"
+)
+
+// ServiceInfos returns the registered services, except the ops servers. It
+// implements ops.AdminController.
+func (l *launcher) ServiceInfos() []ops.ServiceInfo {
+	services := l.servicesRunner.Services()
+	infos := make([]ops.ServiceInfo, 0, len(services))
+	for _, svc := range services {
+		if svc.internal {
+			continue
+		}
+		infos = append(infos, ops.ServiceInfo{
+			Name:            svc.Name(),
+			State:           svc.State().String(),
+			Restarts:        svc.Restarts(),
+			Uptime:          svc.Uptime(),
+			StartupPriority: svc.Options().StartupPriority,
+			StopPriority:    svc.Options().StopPriority,
+			LastError:       svc.LastError(),
+		})
+	}
+	return infos
+}
+
+// RestartService restarts a service through the services runner. It
+// implements ops.AdminController.
+func (l *launcher) RestartService(ctx context.Context, name string) error {
+	if err := l.controllable(name); err != nil {
+		return err
+	}
+	return l.servicesRunner.Restart(ctx, name)
+}
+
+// StopService stops a service through the services runner. Once ctx is done
+// it returns the ctx error, while the stop goes on. It implements
+// ops.AdminController.
+func (l *launcher) StopService(ctx context.Context, name string) error {
+	if err := l.controllable(name); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.servicesRunner.Stop(name) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown starts the graceful shutdown, as Stop does. It implements
+// ops.AdminController.
+func (l *launcher) Shutdown() { l.Stop() }
+
+// controllable reports the ops servers as not found, so the admin API cannot
+// stop the server serving it.
+func (l *launcher) controllable(name string) error {
+	if svc, ok := l.servicesRunner.Get(name); ok && svc.internal {
+		return fmt.Errorf("%w: [%s]", ErrServiceNotFound, name)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+	"github.com/tkcrm/mx/launcher/ops"
+)
+
+func TestServicesRunner_Stop(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var starts, stops atomic.Int32
+
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("pool"),
+				launcher.WithStart(func(ctx context.Context) error {
+					starts.Add(1)
+					<-ctx.Done()
+					return nil
+				}),
+				launcher.WithStop(func(context.Context) error {
+					stops.Add(1)
+					return nil
+				}),
+			),
+			launcher.NewService(
+				launcher.WithServiceName("api"),
+				launcher.WithStart(blockingStart),
+				launcher.WithStop(noopStop),
+				launcher.WithDependsOn("pool"),
+			),
+		)
+		errCh := runLauncher(t, ln)
+		runner := ln.ServicesRunner()
+
+		if err := runner.Stop("pool"); err == nil {
+			t.Fatal("Stop error = nil; want the running dependent to prevent it")
+		}
+		if err := runner.Stop("api"); err != nil {
+			t.Fatalf("Stop(api) error: %v", err)
+		}
+		if err := runner.Stop("pool"); err != nil {
+			t.Fatalf("Stop(pool) error: %v", err)
+		}
+		synctest.Wait()
+
+		svc, ok := runner.Get("pool")
+		if !ok {
+			t.Fatal("stopped service was deregistered")
+		}
+		if svc.State() != launcher.ServiceStateStopped || stops.Load() != 1 {
+			t.Fatalf("state = %v, stops = %d; want stopped once", svc.State(), stops.Load())
+		}
+
+		// the launcher keeps running and the service can be started again
+		select {
+		case err := <-errCh:
+			t.Fatalf("Run returned %v after stopping a service", err)
+		default:
+		}
+		if err := runner.Restart(t.Context(), "pool"); err != nil {
+			t.Fatalf("Restart error: %v", err)
+		}
+		synctest.Wait()
+		if starts.Load() != 2 || svc.State() != launcher.ServiceStateRunning {
+			t.Errorf("starts = %d, state = %v; want started again", starts.Load(), svc.State())
+		}
+
+		if err := runner.Stop("missing"); !errors.Is(err, launcher.ErrServiceNotFound) {
+			t.Errorf("Stop(missing) error = %v; want ErrServiceNotFound", err)
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+func TestServicesRunner_Stop_NotRunning(t *testing.T) {
+	ln := newTestLauncher()
+	ln.ServicesRunner().Register(launcher.NewService(
+		launcher.WithServiceName("idle"),
+		launcher.WithStart(blockingStart),
+		launcher.WithStop(noopStop),
+	))
+
+	if err := ln.ServicesRunner().Stop("idle"); !errors.Is(err, launcher.ErrNotRunning) {
+		t.Errorf("Stop before Run error = %v; want ErrNotRunning", err)
+	}
+}
+
+func TestLauncher_AdminController(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+			launcher.WithStartupPriority(2),
+			launcher.WithStopPriority(1),
+		))
+		errCh := runLauncher(t, ln)
+
+		ctl, ok := ln.(ops.AdminController)
+		if !ok {
+			t.Fatal("launcher does not implement ops.AdminController")
+		}
+
+		time.Sleep(time.Minute)
+		infos := ctl.ServiceInfos()
+		if len(infos) != 1 {
+			t.Fatalf("infos = %+v; want 1 service", infos)
+		}
+		info := infos[0]
+		if info.Name != "api" || info.State != "running" || info.Uptime != time.Minute ||
+			info.StartupPriority != 2 || info.StopPriority == nil || *info.StopPriority != 1 {
+			t.Errorf("info = %+v; want the running service", info)
+		}
+
+		if err := ctl.RestartService(t.Context(), "api"); err != nil {
+			t.Fatalf("RestartService error: %v", err)
+		}
+		if info := ctl.ServiceInfos()[0]; info.Restarts != 1 || info.Uptime != 0 {
+			t.Errorf("info = %+v; want 1 restart and the uptime reset", info)
+		}
+
+		if err := ctl.StopService(t.Context(), "api"); err != nil {
+			t.Fatalf("StopService error: %v", err)
+		}
+		if info := ctl.ServiceInfos()[0]; info.State != "stopped" {
+			t.Errorf("state = %s; want stopped", info.State)
+		}
+
+		ctl.Shutdown()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+// A stop outlasting the request context returns its error and goes on.
+func TestLauncher_AdminController_StopServiceContext(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		release := make(chan struct{})
+
+		ln := newTestLauncher()
+		svc := launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(func(context.Context) error {
+				<-release
+				return nil
+			}),
+			launcher.WithShutdownTimeout(time.Hour),
+		)
+		ln.ServicesRunner().Register(svc)
+		errCh := runLauncher(t, ln)
+
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+		if err := ln.(ops.AdminController).StopService(ctx, "api"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("StopService error = %v; want the context error", err)
+		}
+		if svc.State() != launcher.ServiceStateStopping {
+			t.Fatalf("state = %v; want the stop still going on", svc.State())
+		}
+
+		close(release)
+		synctest.Wait()
+		if svc.State() != launcher.ServiceStateStopped {
+			t.Errorf("state = %v; want stopped", svc.State())
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package launcher
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes the delay before a restart.
+type Backoff interface {
+	// Next returns the delay before restart attempt n, counted from 0 since
+	// the service was started or its attempts were reset, given the delay
+	// before the previous restart (zero for the first one).
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+// BackoffFunc adapts a func to a Backoff.
+type BackoffFunc func(attempt int, prev time.Duration) time.Duration
+
+func (f BackoffFunc) Next(attempt int, prev time.Duration) time.Duration {
+	return f(attempt, prev)
+}
+
+// ConstantBackoff waits d before every restart.
+func ConstantBackoff(d time.Duration) Backoff {
+	return BackoffFunc(func(int, time.Duration) time.Duration { return d })
+}
+
+// LinearBackoff waits base, 2*base, 3*base, ... capped at max. A zero max
+// means no cap.
+func LinearBackoff(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		if attempt > 0 && base > math.MaxInt64/time.Duration(attempt+1) {
+			return capDelay(math.MaxInt64, maxDelay)
+		}
+		return capDelay(base*time.Duration(attempt+1), maxDelay)
+	})
+}
+
+// ExponentialBackoff waits base, 2*base, 4*base, ... capped at max. A zero
+// max means no cap.
+func ExponentialBackoff(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return exponential(base, maxDelay, attempt)
+	})
+}
+
+// FullJitterBackoff waits a random delay between zero and the delay of
+// ExponentialBackoff, so that replicas failing together do not restart in
+// lockstep.
+func FullJitterBackoff(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return randomDelay(0, exponential(base, maxDelay, attempt))
+	})
+}
+
+// EqualJitterBackoff waits half the delay of ExponentialBackoff plus a
+// random delay up to the other half, which keeps a minimum wait.
+func EqualJitterBackoff(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		d := exponential(base, maxDelay, attempt)
+		return randomDelay(d/2, d)
+	})
+}
+
+// DecorrelatedJitterBackoff waits a random delay between base and three
+// times the previous delay, capped at max. A zero max means no cap.
+func DecorrelatedJitterBackoff(base, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(_ int, prev time.Duration) time.Duration {
+		upper := max(prev, base)
+		if upper < math.MaxInt64/3 {
+			upper *= 3
+		}
+		return capDelay(randomDelay(base, upper), maxDelay)
+	})
+}
+
+// exponential returns base*2^attempt capped at max, without overflowing.
+func exponential(base, maxDelay time.Duration, attempt int) time.Duration {
+	delay := base
+	for range attempt {
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+		delay *= 2
+		if maxDelay > 0 && delay > maxDelay {
+			break
+		}
+	}
+	return capDelay(delay, maxDelay)
+}
+
+// capDelay caps d at max, unless max is zero.
+func capDelay(d, maxDelay time.Duration) time.Duration {
+	if maxDelay > 0 && d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
+
+// randomDelay returns a random delay in [lo, hi].
+func randomDelay(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	if hi-lo == math.MaxInt64 {
+		return lo + rand.N(hi-lo)
+	}
+	return lo + rand.N(hi-lo+1)
+}
@@ -0,0 +1,185 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+func TestBackoff_Deterministic(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff launcher.Backoff
+		want    []time.Duration
+	}{
+		{
+			name:    "constant",
+			backoff: launcher.ConstantBackoff(time.Second),
+			want:    []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:    "linear capped",
+			backoff: launcher.LinearBackoff(time.Second, 3*time.Second),
+			want:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:    "exponential capped",
+			backoff: launcher.ExponentialBackoff(time.Second, 5*time.Second),
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prev time.Duration
+			for attempt, want := range tt.want {
+				got := tt.backoff.Next(attempt, prev)
+				if got != want {
+					t.Errorf("Next(%d) = %s; want %s", attempt, got, want)
+				}
+				prev = got
+			}
+		})
+	}
+
+	// no overflow without a cap
+	if got := launcher.ExponentialBackoff(time.Second, 0).Next(200, 0); got <= 0 {
+		t.Errorf("Next(200) = %s; want a positive delay", got)
+	}
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	const base, maxDelay = time.Second, 10 * time.Second
+
+	tests := []struct {
+		name    string
+		backoff launcher.Backoff
+		// bounds returns the range of the delay of attempt after prev
+		bounds func(attempt int, prev time.Duration) (time.Duration, time.Duration)
+	}{
+		{
+			name:    "full",
+			backoff: launcher.FullJitterBackoff(base, maxDelay),
+			bounds: func(attempt int, _ time.Duration) (time.Duration, time.Duration) {
+				return 0, min(base<<attempt, maxDelay)
+			},
+		},
+		{
+			name:    "equal",
+			backoff: launcher.EqualJitterBackoff(base, maxDelay),
+			bounds: func(attempt int, _ time.Duration) (time.Duration, time.Duration) {
+				d := min(base<<attempt, maxDelay)
+				return d / 2, d
+			},
+		},
+		{
+			name:    "decorrelated",
+			backoff: launcher.DecorrelatedJitterBackoff(base, maxDelay),
+			bounds: func(_ int, prev time.Duration) (time.Duration, time.Duration) {
+				return base, min(3*max(prev, base), maxDelay)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[time.Duration]bool)
+			for range 100 {
+				var prev time.Duration
+				for attempt := range 6 {
+					got := tt.backoff.Next(attempt, prev)
+					lo, hi := tt.bounds(attempt, prev)
+					if got < lo || got > hi {
+						t.Fatalf("Next(%d, %s) = %s; want within [%s, %s]", attempt, prev, got, lo, hi)
+					}
+					seen[got] = true
+					prev = got
+				}
+			}
+			if len(seen) < 10 {
+				t.Errorf("%d distinct delays in 600 runs; want jitter", len(seen))
+			}
+		})
+	}
+}
+
+func TestService_RestartPolicy_Backoff(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var delays []time.Duration
+		svc := launcher.NewService(
+			launcher.WithServiceName("backoff"),
+			launcher.WithStart(func(context.Context) error { return errors.New("crashed") }),
+			launcher.WithStop(noopStop),
+			launcher.WithRestartPolicy(launcher.RestartPolicy{
+				Mode:       launcher.RestartOnFailure,
+				MaxRetries: 3,
+				Backoff: launcher.BackoffFunc(func(attempt int, prev time.Duration) time.Duration {
+					delays = append(delays, prev)
+					return time.Duration(attempt+1) * time.Second
+				}),
+			}),
+		)
+		svc.Options().Context = context.Background()
+
+		start := time.Now()
+		if err := svc.Start(); err == nil {
+			t.Fatal("Start error = nil; want the failure after the retries")
+		}
+
+		// 1s + 2s + 3s
+		if elapsed := time.Since(start); elapsed != 6*time.Second {
+			t.Errorf("elapsed = %s; want 6s of backoff", elapsed)
+		}
+		want := []time.Duration{0, time.Second, 2 * time.Second}
+		if len(delays) != len(want) {
+			t.Fatalf("previous delays = %v; want %v", delays, want)
+		}
+		for i := range want {
+			if delays[i] != want[i] {
+				t.Errorf("previous delays = %v; want %v", delays, want)
+				break
+			}
+		}
+	})
+}
+
+func TestService_RestartPolicy_ResetAfter(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// runs of 1s crash; every third run is healthy for a minute first
+		attempts := 0
+		svc := launcher.NewService(
+			launcher.WithServiceName("reset"),
+			launcher.WithStart(func(context.Context) error {
+				attempts++
+				if attempts%3 == 0 {
+					time.Sleep(time.Minute)
+				} else {
+					time.Sleep(time.Second)
+				}
+				return errors.New("crashed")
+			}),
+			launcher.WithStop(noopStop),
+			launcher.WithRestartPolicy(launcher.RestartPolicy{
+				Mode:       launcher.RestartOnFailure,
+				MaxRetries: 2,
+				Delay:      time.Second,
+				ResetAfter: 30 * time.Second,
+			}),
+		)
+		svc.Options().Context = context.Background()
+
+		if err := svc.Start(); err == nil {
+			t.Fatal("Start error = nil; want the failure after the retries")
+		}
+
+		// without the reset the service gives up after the third run; the
+		// healthy third run grants two more restarts instead
+		if attempts != 5 {
+			t.Errorf("attempts = %d; want 5", attempts)
+		}
+	})
+}
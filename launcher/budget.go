@@ -0,0 +1,125 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+)
+
+// ErrRestartBudgetExceeded is returned for a restart by RestartPolicy that
+// would exceed the RestartBudget of the launcher.
+var ErrRestartBudgetExceeded = errors.New("restart budget exceeded")
+
+// BudgetAction is what the launcher does once its restart budget is exhausted.
+type BudgetAction int
+
+const (
+	// BudgetActionShutdown refuses the restart: the service fails with
+	// ErrRestartBudgetExceeded, which shuts the application down. It is the
+	// default.
+	BudgetActionShutdown BudgetAction = iota
+	// BudgetActionFailLiveness fails the ops liveness probe from then on, so
+	// the orchestrator replaces the instance. The services keep restarting
+	// according to their policies.
+	BudgetActionFailLiveness
+)
+
+// RestartBudget limits the restarts by RestartPolicy across all services, so
+// a flapping service cannot restart forever.
+type RestartBudget struct {
+	// Max is the number of restarts allowed within Window. Zero disables the
+	// budget.
+	Max int
+
+	// Window is the sliding window the restarts are counted in. Zero counts
+	// all restarts since the launcher started.
+	Window time.Duration
+
+	// Action is taken once a restart exceeds the budget.
+	Action BudgetAction
+}
+
+// restartBudget counts the restarts against a RestartBudget. A nil budget
+// allows every restart.
+type restartBudget struct {
+	budget RestartBudget
+	logger logger.Logger
+
+	mu       sync.Mutex
+	restarts []time.Time
+	// exceeded is the error the budget was first exceeded with, recorded
+	// for BudgetActionFailLiveness only
+	exceeded error
+}
+
+func newRestartBudget(b RestartBudget, log logger.Logger) *restartBudget {
+	if b.Max <= 0 {
+		return nil
+	}
+	return &restartBudget{budget: b, logger: log}
+}
+
+// take counts a restart of the service svc. It returns an error wrapping
+// ErrRestartBudgetExceeded if the restart exceeds the budget and the action
+// is to refuse it.
+func (b *restartBudget) take(svc string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.budget.Window > 0 {
+		i := 0
+		for i < len(b.restarts) && now.Sub(b.restarts[i]) >= b.budget.Window {
+			i++
+		}
+		b.restarts = b.restarts[i:]
+	}
+
+	if len(b.restarts) < b.budget.Max {
+		b.restarts = append(b.restarts, now)
+		return nil
+	}
+
+	err := b.errorf(svc)
+	if b.budget.Action != BudgetActionFailLiveness {
+		return err
+	}
+
+	if b.exceeded == nil {
+		b.exceeded = err
+		b.logger.Errorf("%s, failing the liveness probe", err)
+	}
+	return nil
+}
+
+func (b *restartBudget) errorf(svc string) error {
+	if b.budget.Window > 0 {
+		return fmt.Errorf("%w: restart of service [%s] exceeds %d restart(s) within %s", ErrRestartBudgetExceeded, svc, b.budget.Max, b.budget.Window)
+	}
+	return fmt.Errorf("%w: restart of service [%s] exceeds %d restart(s)", ErrRestartBudgetExceeded, svc, b.budget.Max)
+}
+
+// failure returns the error the budget was first exceeded with, if its action
+// is to fail the liveness probe, or nil.
+func (b *restartBudget) failure() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// LivenessFailure reports the exhausted restart budget, if its action is to
+// fail the liveness probe. It implements ops.LivenessReporter.
+func (l *launcher) LivenessFailure() error {
+	return l.budget.failure()
+}
@@ -0,0 +1,168 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+	"github.com/tkcrm/mx/launcher/ops"
+)
+
+// flappingService fails a second after every start and is restarted after a
+// second.
+func flappingService(name string) *launcher.Service {
+	return launcher.NewService(
+		launcher.WithServiceName(name),
+		launcher.WithStart(func(context.Context) error {
+			time.Sleep(time.Second)
+			return errors.New("crashed")
+		}),
+		launcher.WithStop(noopStop),
+		launcher.WithRestartPolicy(launcher.RestartPolicy{
+			Mode:    launcher.RestartOnFailure,
+			Backoff: launcher.ConstantBackoff(time.Second),
+		}),
+	)
+}
+
+func TestLauncher_RestartBudget_Shutdown(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithRestartBudget(launcher.RestartBudget{Max: 3, Window: time.Minute}),
+		)
+		a, b := flappingService("a"), flappingService("b")
+		ln.ServicesRunner().Register(a, b)
+
+		err := ln.Run()
+		if !errors.Is(err, launcher.ErrRestartBudgetExceeded) {
+			t.Fatalf("Run error = %v; want ErrRestartBudgetExceeded", err)
+		}
+
+		var startErr *launcher.StartError
+		if !errors.As(err, &startErr) {
+			t.Fatalf("Run error = %v; want *StartError", err)
+		}
+
+		if restarts := a.Restarts() + b.Restarts(); restarts != 3 {
+			t.Errorf("restarts = %d; want 3 across the services", restarts)
+		}
+	})
+}
+
+func TestLauncher_RestartBudget_Window(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithRestartBudget(launcher.RestartBudget{Max: 2, Window: 4 * time.Second}),
+		)
+		// restarts every 2s, so the restarts older than the window make room
+		// for the new ones and the budget is never exceeded
+		svc := flappingService("a")
+		ln.ServicesRunner().Register(svc)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+
+		// stop during a backoff, as a run crashing on shutdown is a failure
+		time.Sleep(19*time.Second + 500*time.Millisecond)
+		synctest.Wait()
+		ln.Stop()
+
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error = %v; want nil", err)
+		}
+		if svc.Restarts() != 10 {
+			t.Errorf("restarts = %d; want 10", svc.Restarts())
+		}
+	})
+}
+
+func TestLauncher_RestartBudget_FailLiveness(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithRestartBudget(launcher.RestartBudget{
+				Max:    2,
+				Action: launcher.BudgetActionFailLiveness,
+			}),
+		)
+		svc := flappingService("a")
+		ln.ServicesRunner().Register(svc)
+
+		reporter, ok := ln.(ops.LivenessReporter)
+		if !ok {
+			t.Fatal("launcher does not implement ops.LivenessReporter")
+		}
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+
+		time.Sleep(3 * time.Second)
+		synctest.Wait()
+		if err := reporter.LivenessFailure(); err != nil {
+			t.Errorf("LivenessFailure = %v; want nil within the budget", err)
+		}
+
+		time.Sleep(10 * time.Second)
+		synctest.Wait()
+		if err := reporter.LivenessFailure(); !errors.Is(err, launcher.ErrRestartBudgetExceeded) {
+			t.Errorf("LivenessFailure = %v; want ErrRestartBudgetExceeded", err)
+		}
+		// the service keeps restarting
+		if svc.Restarts() <= 2 {
+			t.Errorf("restarts = %d; want more than the budget", svc.Restarts())
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Errorf("Run error = %v; want nil", err)
+		}
+	})
+}
+
+func TestService_RestartPolicy_Breaker(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var starts []time.Duration
+		begin := time.Now()
+
+		svc := launcher.NewService(
+			launcher.WithServiceName("breaker"),
+			launcher.WithStart(func(context.Context) error {
+				starts = append(starts, time.Since(begin))
+				return errors.New("crashed")
+			}),
+			launcher.WithStop(noopStop),
+			launcher.WithRestartPolicy(launcher.RestartPolicy{
+				Mode:       launcher.RestartOnFailure,
+				MaxRetries: 6,
+				Backoff:    launcher.ConstantBackoff(time.Second),
+				Breaker: launcher.Breaker{
+					Failures: 3,
+					Window:   time.Minute,
+					Cooldown: 30 * time.Second,
+				},
+			}),
+		)
+		svc.Options().Context = context.Background()
+
+		if err := svc.Start(); err == nil {
+			t.Fatal("Start error = nil; want the failure after the retries")
+		}
+
+		// every third failure opens the breaker for 30s
+		want := []time.Duration{0, 1, 2, 32, 33, 34, 64}
+		if len(starts) != len(want) {
+			t.Fatalf("starts = %v; want at %v seconds", starts, want)
+		}
+		for i := range want {
+			if starts[i] != want[i]*time.Second {
+				t.Errorf("starts = %v; want at %v seconds", starts, want)
+				break
+			}
+		}
+	})
+}
@@ -427,7 +427,7 @@ func TestService_Stop_AfterStopHook_Error(t *testing.T) {
 	})
 }
 
-// --- Stop-sequence error logging (None / Fifo / Lifo) ---
+// --- Stop-sequence errors (None / Fifo / Lifo) ---
 
 func runLauncherWithFailingStop(t *testing.T, seq launcher.RunnerServicesSequence) {
 	t.Helper()
@@ -448,9 +448,13 @@ func runLauncherWithFailingStop(t *testing.T, seq launcher.RunnerServicesSequenc
 		ln.Stop()
 		synctest.Wait()
 
-		// Stop errors are logged, not returned by Run.
-		if err := <-errCh; err != nil {
-			t.Fatalf("Run error = %v; want nil (stop errors only logged)", err)
+		// Stop errors are logged and returned by Run.
+		var stopErr *launcher.StopError
+		if err := <-errCh; !errors.As(err, &stopErr) {
+			t.Fatalf("Run error = %v; want *StopError", err)
+		}
+		if stopErr.Service != "bad-stop" || stopErr.Phase != launcher.PhaseStop {
+			t.Errorf("StopError = %+v; want service bad-stop in phase stop", stopErr)
 		}
 	})
 }
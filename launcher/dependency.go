@@ -0,0 +1,148 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrUnknownDependency is returned by Run when a service depends on a name
+	// that is not registered.
+	ErrUnknownDependency = errors.New("unknown service dependency")
+	// ErrDependencyCycle is returned by Run when service dependencies form a cycle.
+	ErrDependencyCycle = errors.New("service dependency cycle")
+)
+
+// dependencyGraph resolves the names declared with WithDependsOn into links
+// between registered services.
+type dependencyGraph struct {
+	// deps maps a service to the services it depends on.
+	deps map[*Service][]*Service
+	// dependents maps a service to the services that depend on it.
+	dependents map[*Service][]*Service
+}
+
+// newDependencyGraph builds the dependency graph of services and validates it.
+// It fails on unknown or ambiguous dependency names and on cycles.
+func newDependencyGraph(services []*Service) (*dependencyGraph, error) {
+	g := &dependencyGraph{
+		deps:       make(map[*Service][]*Service),
+		dependents: make(map[*Service][]*Service),
+	}
+
+	byName := make(map[string][]*Service, len(services))
+	for _, svc := range services {
+		byName[svc.Name()] = append(byName[svc.Name()], svc)
+	}
+
+	for _, svc := range services {
+		for _, name := range svc.Options().DependsOn {
+			targets := byName[name]
+			switch {
+			case len(targets) == 0:
+				return nil, fmt.Errorf("%w: service [%s] depends on [%s]", ErrUnknownDependency, svc.Name(), name)
+			case len(targets) > 1:
+				return nil, fmt.Errorf("service [%s] depends on [%s], which is registered more than once", svc.Name(), name)
+			}
+
+			dep := targets[0]
+			if slices.Contains(g.deps[svc], dep) {
+				continue
+			}
+			g.deps[svc] = append(g.deps[svc], dep)
+			g.dependents[dep] = append(g.dependents[dep], svc)
+		}
+	}
+
+	if cycle := g.findCycle(services); len(cycle) > 0 {
+		names := make([]string, len(cycle))
+		for i, svc := range cycle {
+			names[i] = svc.Name()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(names, " -> "))
+	}
+
+	return g, nil
+}
+
+// dependencies returns the services svc depends on.
+func (g *dependencyGraph) dependencies(svc *Service) []*Service { return g.deps[svc] }
+
+// findCycle returns the first dependency cycle found, with the starting service
+// repeated at the end (a -> b -> a), or nil if the graph is acyclic.
+func (g *dependencyGraph) findCycle(services []*Service) []*Service {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	marks := make(map[*Service]int, len(services))
+	var path []*Service
+
+	var visit func(svc *Service) []*Service
+	visit = func(svc *Service) []*Service {
+		marks[svc] = inProgress
+		path = append(path, svc)
+
+		for _, dep := range g.deps[svc] {
+			switch marks[dep] {
+			case inProgress:
+				start := slices.Index(path, dep)
+				return append(slices.Clone(path[start:]), dep)
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		marks[svc] = done
+		return nil
+	}
+
+	for _, svc := range services {
+		if marks[svc] == unvisited {
+			if cycle := visit(svc); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// stopOrder returns services ordered so that every service comes before the
+// services it depends on. Among services free to stop, the order of the input
+// slice is preserved.
+func (g *dependencyGraph) stopOrder(services []*Service) []*Service {
+	pending := make(map[*Service]int, len(services))
+	for _, svc := range services {
+		for _, dep := range g.deps[svc] {
+			pending[dep]++
+		}
+	}
+
+	res := make([]*Service, 0, len(services))
+	remaining := slices.Clone(services)
+	for len(remaining) > 0 {
+		idx := slices.IndexFunc(remaining, func(svc *Service) bool { return pending[svc] == 0 })
+		if idx < 0 {
+			// unreachable for a validated graph; keep the remaining order
+			return append(res, remaining...)
+		}
+
+		svc := remaining[idx]
+		remaining = slices.Delete(remaining, idx, idx+1)
+		res = append(res, svc)
+
+		for _, dep := range g.deps[svc] {
+			pending[dep]--
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,273 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+// orderRecorder collects lifecycle markers from concurrently running services.
+type orderRecorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *orderRecorder) add(s string) {
+	r.mu.Lock()
+	r.order = append(r.order, s)
+	r.mu.Unlock()
+}
+
+func (r *orderRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.order)
+}
+
+// A dependent service must not start until its dependency reports ready.
+func TestLauncher_DependsOn_WaitsForReadiness(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var apiStarted atomic.Bool
+
+		ln := newTestLauncher()
+
+		db := newReadinessService("db")
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("api"),
+				launcher.WithDependsOn("db"),
+				launcher.WithStart(func(ctx context.Context) error {
+					apiStarted.Store(true)
+					<-ctx.Done()
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			),
+			launcher.NewService(launcher.WithService(db)),
+		)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+
+		if apiStarted.Load() {
+			t.Fatal("api started before db reported ready")
+		}
+
+		db.markReady()
+		synctest.Wait()
+
+		if !apiStarted.Load() {
+			t.Fatal("api did not start after db reported ready")
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+// Dependencies override startup priority: a priority-1 service may depend on a
+// default priority-0 service without deadlocking.
+func TestLauncher_DependsOn_IgnoresStartupPriority(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		rec := new(orderRecorder)
+
+		ln := newTestLauncher()
+
+		makeSvc := func(name string, opts ...launcher.ServiceOption) *launcher.Service {
+			return launcher.NewService(append([]launcher.ServiceOption{
+				launcher.WithServiceName(name),
+				launcher.WithStart(func(ctx context.Context) error {
+					rec.add(name)
+					<-ctx.Done()
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			}, opts...)...)
+		}
+
+		cacheReady := make(chan struct{})
+		ln.ServicesRunner().Register(
+			makeSvc("worker", launcher.WithStartupPriority(1), launcher.WithDependsOn("cache")),
+			launcher.NewService(
+				launcher.WithServiceName("cache"),
+				launcher.WithReadiness(cacheReady),
+				launcher.WithStart(func(ctx context.Context) error {
+					rec.add("cache")
+					close(cacheReady)
+					<-ctx.Done()
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			),
+		)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+
+		if got := rec.get(); !slices.Equal(got, []string{"cache", "worker"}) {
+			t.Fatalf("start order = %v; want [cache worker]", got)
+		}
+	})
+}
+
+func TestLauncher_DependsOn_UnknownDependency(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var started atomic.Bool
+		var beforeStart atomic.Bool
+
+		ln := newTestLauncher(
+			launcher.WithBeforeStart(func() error { beforeStart.Store(true); return nil }),
+		)
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("api"),
+				launcher.WithDependsOn("missing"),
+				launcher.WithStart(func(ctx context.Context) error {
+					started.Store(true)
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			),
+		)
+
+		err := ln.Run()
+		if !errors.Is(err, launcher.ErrUnknownDependency) {
+			t.Fatalf("Run error = %v; want ErrUnknownDependency", err)
+		}
+		if started.Load() || beforeStart.Load() {
+			t.Fatal("nothing must start when the dependency graph is invalid")
+		}
+	})
+}
+
+func TestLauncher_DependsOn_Cycle(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher()
+
+		for _, pair := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}} {
+			ln.ServicesRunner().Register(
+				launcher.NewService(
+					launcher.WithServiceName(pair[0]),
+					launcher.WithDependsOn(pair[1]),
+					launcher.WithStart(blockingStart),
+					launcher.WithStop(noopStop),
+				),
+			)
+		}
+
+		err := ln.Run()
+		if !errors.Is(err, launcher.ErrDependencyCycle) {
+			t.Fatalf("Run error = %v; want ErrDependencyCycle", err)
+		}
+		if want := "a -> b -> c -> a"; !strings.Contains(err.Error(), want) {
+			t.Fatalf("Run error = %q; want it to contain %q", err, want)
+		}
+	})
+}
+
+// A dependency failing before it reports ready must keep its dependents from
+// starting.
+func TestLauncher_DependsOn_DependencyFails(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var apiStarted atomic.Bool
+		errDB := errors.New("db failed")
+
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("db"),
+				launcher.WithReadiness(make(chan struct{})),
+				launcher.WithStart(func(context.Context) error { return errDB }),
+				launcher.WithStop(noopStop),
+			),
+			launcher.NewService(
+				launcher.WithServiceName("api"),
+				launcher.WithDependsOn("db"),
+				launcher.WithStart(func(ctx context.Context) error {
+					apiStarted.Store(true)
+					<-ctx.Done()
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			),
+		)
+
+		err := ln.Run()
+		if !errors.Is(err, errDB) {
+			t.Fatalf("Run error = %v; want %v", err, errDB)
+		}
+		if apiStarted.Load() {
+			t.Fatal("api started although its dependency failed")
+		}
+	})
+}
+
+// Services stop in reverse dependency order in every stop sequence.
+func TestLauncher_DependsOn_StopOrder(t *testing.T) {
+	sequences := map[string]launcher.RunnerServicesSequence{
+		"none": launcher.RunnerServicesSequenceNone,
+		"fifo": launcher.RunnerServicesSequenceFifo,
+		"lifo": launcher.RunnerServicesSequenceLifo,
+	}
+
+	for name, seq := range sequences {
+		t.Run(name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				rec := new(orderRecorder)
+
+				ln := newTestLauncher(launcher.WithRunnerServicesSequence(seq))
+
+				makeSvc := func(name string, deps ...string) *launcher.Service {
+					return launcher.NewService(
+						launcher.WithServiceName(name),
+						launcher.WithDependsOn(deps...),
+						launcher.WithStart(blockingStart),
+						launcher.WithStop(func(context.Context) error {
+							rec.add(name)
+							return nil
+						}),
+					)
+				}
+
+				// registered dependencies-first, so FIFO alone would stop db first
+				ln.ServicesRunner().Register(
+					makeSvc("db"),
+					makeSvc("repo", "db"),
+					makeSvc("api", "repo"),
+				)
+
+				errCh := make(chan error, 1)
+				go func() { errCh <- ln.Run() }()
+				synctest.Wait()
+
+				ln.Stop()
+				synctest.Wait()
+				if err := <-errCh; err != nil {
+					t.Fatalf("Run error: %v", err)
+				}
+
+				if got := rec.get(); !slices.Equal(got, []string{"api", "repo", "db"}) {
+					t.Fatalf("stop order = %v; want [api repo db]", got)
+				}
+			})
+		})
+	}
+}
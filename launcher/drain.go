@@ -0,0 +1,118 @@
+package launcher
+
+import (
+	"strings"
+	"time"
+)
+
+// drainPollInterval is how often the in-flight requests are polled while
+// draining.
+const drainPollInterval = 50 * time.Millisecond
+
+// DrainPolicy configures the drain phase between a shutdown signal and the
+// stop of the services. While draining, the ops readiness probe reports
+// "draining" so load balancers take the instance out of rotation, but the
+// services keep serving.
+type DrainPolicy struct {
+	// Delay is how long to wait before the services are stopped, e.g. a few
+	// readiness probe periods.
+	Delay time.Duration
+
+	// WaitInFlight also waits, after Delay, until no service reports requests
+	// in flight (see WithInFlight and mxtypes.InFlightReporter).
+	WaitInFlight bool
+
+	// InFlightTimeout bounds the wait for the in-flight requests. Zero means
+	// no limit other than the GlobalShutdownTimeout, which covers the drain.
+	InFlightTimeout time.Duration
+}
+
+// String describes the drain, e.g. "5s, then for the requests in flight up
+// to 10s".
+func (p DrainPolicy) String() string {
+	var parts []string
+	if p.Delay > 0 {
+		parts = append(parts, p.Delay.String())
+	}
+	if p.WaitInFlight {
+		wait := "for the requests in flight"
+		if p.InFlightTimeout > 0 {
+			wait += " up to " + p.InFlightTimeout.String()
+		}
+		parts = append(parts, wait)
+	}
+	return strings.Join(parts, ", then ")
+}
+
+// Draining reports whether the launcher is draining before shutdown. It
+// implements ops.DrainReporter.
+func (l *launcher) Draining() bool { return l.draining.Load() }
+
+// drain flips readiness to draining and waits as configured by DrainPolicy.
+// It returns early when the launcher context is done.
+func (l *launcher) drain() {
+	l.draining.Store(true)
+
+	p := l.opts.DrainPolicy
+	if p.Delay <= 0 && !p.WaitInFlight {
+		return
+	}
+
+	l.opts.logger.Infof("draining %s before stopping services", p)
+
+	if p.Delay > 0 {
+		timer := time.NewTimer(p.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-l.opts.Context.Done():
+			return
+		}
+	}
+
+	if !p.WaitInFlight {
+		return
+	}
+
+	var deadline <-chan time.Time
+	if p.InFlightTimeout > 0 {
+		timer := time.NewTimer(p.InFlightTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		n := l.inFlight()
+		if n == 0 {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			l.opts.logger.Infof("drain in-flight timeout exceeded, %d requests still in flight", n)
+			return
+		case <-l.opts.Context.Done():
+			return
+		}
+	}
+}
+
+// inFlight returns the number of requests in flight across all services
+// except the ops servers, which keep serving the probes.
+func (l *launcher) inFlight() int64 {
+	var n int64
+	for _, svc := range l.servicesRunner.Services() {
+		if svc.internal {
+			continue
+		}
+		if fn := svc.Options().InFlight; fn != nil {
+			n += fn()
+		}
+	}
+	return n
+}
@@ -0,0 +1,205 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+)
+
+// fakeSignals replaces the OS signal notification for the duration of the
+// test and returns a function delivering a signal to the launcher.
+func fakeSignals(t *testing.T) func(os.Signal) {
+	t.Helper()
+
+	registered := make(chan chan<- os.Signal, 1)
+	notifySignals = func(c chan<- os.Signal, _ ...os.Signal) { registered <- c }
+	t.Cleanup(func() { notifySignals = signal.Notify })
+
+	var c chan<- os.Signal
+	return func(sig os.Signal) {
+		if c == nil {
+			select {
+			case c = <-registered:
+			case <-time.After(10 * time.Second):
+				t.Fatal("launcher did not watch for signals")
+			}
+		}
+		c <- sig
+	}
+}
+
+// drainLauncher runs a launcher with a single service and returns it with a
+// channel closed once the service context is done.
+func drainLauncher(t *testing.T, p DrainPolicy, opts ...ServiceOption) (*launcher, <-chan struct{}, <-chan error) {
+	t.Helper()
+
+	stopped := make(chan struct{})
+	ln := New(
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithDrainPolicy(p),
+	).(*launcher)
+	ln.ServicesRunner().Register(NewService(append([]ServiceOption{
+		WithServiceName("api"),
+		WithStart(func(ctx context.Context) error { <-ctx.Done(); close(stopped); return nil }),
+		WithStop(func(context.Context) error { return nil }),
+	}, opts...)...))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	return ln, stopped, errCh
+}
+
+func TestLauncher_Drain_Delay(t *testing.T) {
+	sendSignal := fakeSignals(t)
+	ln, stopped, errCh := drainLauncher(t, DrainPolicy{Delay: 200 * time.Millisecond})
+
+	sendSignal(syscall.SIGTERM)
+	start := time.Now()
+
+	select {
+	case <-stopped:
+		t.Fatal("service was stopped before the drain delay")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if !ln.Draining() {
+		t.Error("Draining = false during the drain delay")
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Run returned %s after the signal; want at least the drain delay", elapsed)
+	}
+}
+
+func TestLauncher_Drain_WaitInFlight(t *testing.T) {
+	var inFlight atomic.Int64
+	inFlight.Store(2)
+
+	sendSignal := fakeSignals(t)
+	_, stopped, errCh := drainLauncher(t,
+		DrainPolicy{WaitInFlight: true},
+		WithInFlight(inFlight.Load),
+	)
+
+	sendSignal(syscall.SIGTERM)
+
+	select {
+	case <-stopped:
+		t.Fatal("service was stopped with requests in flight")
+	case <-time.After(3 * drainPollInterval):
+	}
+
+	inFlight.Store(0)
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+}
+
+func TestLauncher_Drain_InFlightTimeout(t *testing.T) {
+	var inFlight atomic.Int64
+	inFlight.Store(1)
+
+	sendSignal := fakeSignals(t)
+	_, _, errCh := drainLauncher(t,
+		DrainPolicy{WaitInFlight: true, InFlightTimeout: 100 * time.Millisecond},
+		WithInFlight(inFlight.Load),
+	)
+
+	sendSignal(syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("drain did not give up after the in-flight timeout")
+	}
+}
+
+// The global shutdown timeout covers the drain, so requests that never end
+// do not hold the shutdown open.
+func TestLauncher_Drain_GlobalShutdownTimeout(t *testing.T) {
+	sendSignal := fakeSignals(t)
+	exits := make(chan int, 1)
+
+	ln := New(
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithDrainPolicy(DrainPolicy{WaitInFlight: true}),
+		WithGlobalShutdownTimeout(100*time.Millisecond),
+		WithExitFunc(func(code int) { exits <- code }),
+	)
+	ln.ServicesRunner().Register(NewService(
+		WithServiceName("stream"),
+		WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+		WithStop(func(context.Context) error { return nil }),
+		WithInFlight(func() int64 { return 1 }),
+	))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	sendSignal(syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		var fse *ForcedShutdownError
+		if !errors.As(err, &fse) || fse.Reason != "global shutdown timeout exceeded" {
+			t.Fatalf("Run error = %v; want a forced shutdown by the global timeout", err)
+		}
+		if len(fse.Pending) != 1 || fse.Pending[0].Service != "stream" {
+			t.Errorf("Pending = %+v; want the draining service", fse.Pending)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the drain outlasted the global shutdown timeout")
+	}
+	if code := <-exits; code != 1 {
+		t.Errorf("exit code = %d; want 1", code)
+	}
+}
+
+func TestDrainPolicy_String(t *testing.T) {
+	tests := []struct {
+		policy DrainPolicy
+		want   string
+	}{
+		{DrainPolicy{Delay: 5 * time.Second}, "5s"},
+		{DrainPolicy{WaitInFlight: true}, "for the requests in flight"},
+		{DrainPolicy{Delay: 5 * time.Second, WaitInFlight: true, InFlightTimeout: 10 * time.Second}, "5s, then for the requests in flight up to 10s"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.String(); got != tt.want {
+			t.Errorf("%+v: String() = %q; want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+// Stop cancels the launcher at once, without draining.
+func TestLauncher_Drain_StopSkipsDrain(t *testing.T) {
+	fakeSignals(t)
+	ln, _, errCh := drainLauncher(t, DrainPolicy{Delay: time.Hour})
+
+	ln.Stop()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Stop waited for the drain delay")
+	}
+	if ln.Draining() {
+		t.Error("Draining = true after Stop")
+	}
+}
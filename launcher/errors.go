@@ -0,0 +1,171 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase is the lifecycle phase in which an error occurred.
+type Phase string
+
+const (
+	// PhaseStart is the run of a service Start func, including its restarts.
+	PhaseStart Phase = "start"
+	// PhaseStop is the run of a service Stop func.
+	PhaseStop Phase = "stop"
+	// PhaseBeforeStart is the run of the before start hooks.
+	PhaseBeforeStart Phase = "before_start"
+	// PhaseAfterStart is the run of the after start hooks.
+	PhaseAfterStart Phase = "after_start"
+	// PhaseAfterStartFinished is the run of the service hooks called once its
+	// Start func returned.
+	PhaseAfterStartFinished Phase = "after_start_finished"
+	// PhaseBeforeStop is the run of the before stop hooks.
+	PhaseBeforeStop Phase = "before_stop"
+	// PhaseAfterStop is the run of the after stop hooks.
+	PhaseAfterStop Phase = "after_stop"
+)
+
+// StartError is a failure of a service to start or to keep running.
+type StartError struct {
+	Service string
+	Phase   Phase
+	// Attempt is the start attempt that failed, starting at 1.
+	Attempt int
+	Err     error
+}
+
+func (e *StartError) Error() string {
+	return fmt.Sprintf("failed to start service [%s]: %s", e.Service, e.Err)
+}
+
+func (e *StartError) Unwrap() error { return e.Err }
+
+// StopError is a failure of a service Stop func.
+type StopError struct {
+	Service string
+	Phase   Phase
+	// Attempt is the start attempt of the run that was stopped.
+	Attempt int
+	Err     error
+}
+
+func (e *StopError) Error() string {
+	return fmt.Sprintf("failed to stop service [%s]: %s", e.Service, e.Err)
+}
+
+func (e *StopError) Unwrap() error { return e.Err }
+
+// StartupTimeoutError reports a service that did not become ready within its
+// StartupTimeout.
+type StartupTimeoutError struct {
+	Service string
+	Phase   Phase
+	Attempt int
+	Timeout time.Duration
+}
+
+func (e *StartupTimeoutError) Error() string {
+	return fmt.Sprintf("service [%s] startup timeout exceeded (%s)", e.Service, e.Timeout)
+}
+
+func (e *StartupTimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+// HookError is a failure of a before or after hook, of a service or of the
+// launcher itself, in which case Service is empty.
+type HookError struct {
+	Service string
+	Phase   Phase
+	// Attempt is the start attempt of the service the hook ran for, or zero
+	// for the launcher hooks.
+	Attempt int
+	// Hook is the name of the hook, empty for unnamed hooks.
+	Hook string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.subject(), e.Err)
+}
+
+// subject names the hook, e.g. "before start hook [migrate] of service [db]".
+func (e *HookError) subject() string {
+	hook := strings.ReplaceAll(string(e.Phase), "_", " ") + " hook"
+	if e.Hook != "" {
+		hook += " [" + e.Hook + "]"
+	}
+	if e.Service != "" {
+		hook += " of service [" + e.Service + "]"
+	}
+	return hook
+}
+
+func (e *HookError) Unwrap() error { return e.Err }
+
+// startError attributes err, returned by the start of the service svc, to it.
+// Errors that are already attributed are returned as they are.
+func startError(svc *Service, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		startErr   *StartError
+		hookErr    *HookError
+		timeoutErr *StartupTimeoutError
+	)
+	if errors.As(err, &startErr) || errors.As(err, &hookErr) || errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	return &StartError{
+		Service: svc.Name(),
+		Phase:   PhaseStart,
+		Attempt: int(svc.attempt.Load()),
+		Err:     err,
+	}
+}
+
+// failures collects the errors of the services failing while the launcher
+// runs, including those added at runtime.
+type failures struct {
+	mu   sync.Mutex
+	errs []error
+	// notify is signalled on a failure
+	notify chan struct{}
+}
+
+func newFailures() *failures {
+	return &failures{notify: make(chan struct{}, 1)}
+}
+
+// add records err and signals it.
+func (f *failures) add(err error) {
+	f.mu.Lock()
+	f.errs = append(f.errs, err)
+	f.mu.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// failed returns a channel signalled after a failure was recorded.
+func (f *failures) failed() <-chan struct{} { return f.notify }
+
+// join joins err with the recorded failures. Called once the services
+// returned, it holds every failure.
+func (f *failures) join(err error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.errs) == 0 {
+		return err
+	}
+	return errors.Join(append([]error{err}, f.errs...)...)
+}
@@ -0,0 +1,248 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+// startErrorOf returns the *StartError of the named service in err, or nil.
+func startErrorOf(err error, service string) *launcher.StartError {
+	var startErr *launcher.StartError
+	if errors.As(err, &startErr) && startErr.Service == service {
+		return startErr
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if startErr := startErrorOf(e, service); startErr != nil {
+				return startErr
+			}
+		}
+	}
+	return nil
+}
+
+func TestLauncher_Errors_JoinsStartErrors(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		errA := errors.New("a crashed")
+		errB := errors.New("b crashed")
+
+		// both services fail at the same time, once both are running
+		var running sync.WaitGroup
+		running.Add(2)
+		fail := func(err error) func(context.Context) error {
+			return func(context.Context) error {
+				running.Done()
+				running.Wait()
+				return err
+			}
+		}
+
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("a"),
+				launcher.WithStart(fail(errA)),
+				launcher.WithStop(noopStop),
+			),
+			launcher.NewService(
+				launcher.WithServiceName("b"),
+				launcher.WithStart(fail(errB)),
+				launcher.WithStop(noopStop),
+			),
+		)
+
+		err := ln.Run()
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Fatalf("Run error = %v; want both service errors", err)
+		}
+
+		for service, want := range map[string]error{"a": errA, "b": errB} {
+			startErr := startErrorOf(err, service)
+			if startErr == nil {
+				t.Errorf("Run error = %v; want a *StartError of service %s", err, service)
+				continue
+			}
+			if startErr.Phase != launcher.PhaseStart || startErr.Attempt != 1 || !errors.Is(startErr, want) {
+				t.Errorf("StartError = %+v; want %v in phase start, attempt 1", startErr, want)
+			}
+		}
+	})
+}
+
+// A service failing while the launcher stops on request is reported by Run.
+func TestLauncher_Errors_FailureOnShutdown(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		flushErr := errors.New("flush failed")
+
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("writer"),
+				launcher.WithStart(func(ctx context.Context) error {
+					<-ctx.Done()
+					return flushErr
+				}),
+				launcher.WithStop(noopStop),
+			),
+			launcher.NewService(
+				launcher.WithServiceName("api"),
+				launcher.WithStart(blockingStart),
+				launcher.WithStop(noopStop),
+			),
+		)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+		ln.Stop()
+
+		err := <-errCh
+		if !errors.Is(err, flushErr) {
+			t.Fatalf("Run error = %v; want the failure on shutdown", err)
+		}
+		if startErr := startErrorOf(err, "writer"); startErr == nil {
+			t.Errorf("Run error = %v; want a *StartError of service writer", err)
+		}
+	})
+}
+
+func TestLauncher_Errors_JoinsHookErrors(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		errA := errors.New("a crashed")
+		errB := errors.New("b hook failed")
+
+		// a fails once b is in its before start hook, which fails in turn
+		// when the launcher shuts down, so both failures reach Run
+		bEntered := make(chan struct{})
+
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("a"),
+				launcher.WithStart(func(context.Context) error { <-bEntered; return errA }),
+				launcher.WithStop(noopStop),
+			),
+			launcher.NewService(
+				launcher.WithServiceName("b"),
+				launcher.WithStart(blockingStart),
+				launcher.WithStop(noopStop),
+				launcher.WithServiceBeforeStartHook(launcher.Hook{
+					Name: "migrate",
+					Fn: func(ctx context.Context) error {
+						close(bEntered)
+						<-ctx.Done()
+						return errB
+					},
+				}),
+			),
+		)
+
+		err := ln.Run()
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Fatalf("Run error = %v; want both service errors", err)
+		}
+
+		var startErr *launcher.StartError
+		if !errors.As(err, &startErr) {
+			t.Fatalf("Run error = %v; want *StartError", err)
+		}
+		if startErr.Service != "a" || startErr.Phase != launcher.PhaseStart || startErr.Attempt != 1 {
+			t.Errorf("StartError = %+v; want service a in phase start, attempt 1", startErr)
+		}
+
+		var hookErr *launcher.HookError
+		if !errors.As(err, &hookErr) {
+			t.Fatalf("Run error = %v; want *HookError", err)
+		}
+		if hookErr.Service != "b" || hookErr.Hook != "migrate" || hookErr.Phase != launcher.PhaseBeforeStart {
+			t.Errorf("HookError = %+v; want hook migrate of service b in phase before_start", hookErr)
+		}
+	})
+}
+
+func TestLauncher_Errors_StartupTimeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("slow"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+			launcher.WithReadiness(make(chan struct{})),
+			launcher.WithStartupTimeout(time.Second),
+		))
+
+		var timeoutErr *launcher.StartupTimeoutError
+		if err := ln.Run(); !errors.As(err, &timeoutErr) {
+			t.Fatalf("Run error = %v; want *StartupTimeoutError", err)
+		}
+		if timeoutErr.Service != "slow" || timeoutErr.Timeout != time.Second {
+			t.Errorf("StartupTimeoutError = %+v; want service slow, timeout 1s", timeoutErr)
+		}
+		if !errors.Is(timeoutErr, context.DeadlineExceeded) {
+			t.Errorf("StartupTimeoutError does not wrap context.DeadlineExceeded")
+		}
+	})
+}
+
+func TestLauncher_Errors_Hooks(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		hookErr := errors.New("migrations failed")
+
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("db"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+			launcher.WithServiceBeforeStart(func() error { return hookErr }),
+		))
+
+		var hErr *launcher.HookError
+		if err := ln.Run(); !errors.As(err, &hErr) {
+			t.Fatalf("Run error = %v; want *HookError", err)
+		}
+		if hErr.Service != "db" || hErr.Phase != launcher.PhaseBeforeStart || !errors.Is(hErr, hookErr) {
+			t.Errorf("HookError = %+v; want service db in phase before_start", hErr)
+		}
+	})
+
+	synctest.Test(t, func(t *testing.T) {
+		hookErr := errors.New("flush failed")
+
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithAfterStop(func() error { return hookErr }),
+		)
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(func(context.Context) error { return errors.New("stop failed") }),
+		))
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+		ln.Stop()
+
+		err := <-errCh
+
+		var hErr *launcher.HookError
+		if !errors.As(err, &hErr) {
+			t.Fatalf("Run error = %v; want *HookError", err)
+		}
+		if hErr.Service != "" || hErr.Phase != launcher.PhaseAfterStop {
+			t.Errorf("HookError = %+v; want launcher hook in phase after_stop", hErr)
+		}
+
+		// the stop failure is reported alongside the hook failure
+		var stopErr *launcher.StopError
+		if !errors.As(err, &stopErr) || stopErr.Service != "api" {
+			t.Errorf("Run error = %v; want *StopError of api", err)
+		}
+	})
+}
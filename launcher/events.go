@@ -0,0 +1,68 @@
+package launcher
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// EventReasonRestartPolicy marks the transitions of a restart made by RestartPolicy.
+	EventReasonRestartPolicy = "restart_policy"
+	// EventReasonRestartRequested marks the transitions of an on-demand restart.
+	EventReasonRestartRequested = "restart_requested"
+	// EventReasonStopRequested marks the transitions of an on-demand stop.
+	EventReasonStopRequested = "stop_requested"
+	// EventReasonReload marks the event reporting a reload of the service.
+	EventReasonReload = "reload"
+	// EventReasonUnhealthy marks the transitions of a service stopped, and
+	// restarted by RestartPolicy, after its health checks kept failing.
+	EventReasonUnhealthy = "unhealthy"
+)
+
+// LifecycleEvent describes a state transition of a service. A reload is
+// reported as an event with reason EventReasonReload whose From and To are both
+// the current state, and whose Err is the reload error, if any.
+type LifecycleEvent struct {
+	// Service is the name of the service.
+	Service string
+	// From and To are the states before and after the transition.
+	From ServiceState
+	To   ServiceState
+	// Attempt is the start attempt of the current run, starting at 1. It grows
+	// with every restart made by RestartPolicy.
+	Attempt int
+	// Err is the error that caused the transition, if any.
+	Err error
+	// Reason explains transitions not initiated by the service itself, such as
+	// EventReasonRestartPolicy. Empty otherwise.
+	Reason string
+	// Time is when the transition happened.
+	Time time.Time
+}
+
+// eventBus delivers lifecycle events to the subscribed handlers.
+type eventBus struct {
+	mu       sync.RWMutex
+	handlers []func(LifecycleEvent)
+}
+
+func (b *eventBus) subscribe(handler func(LifecycleEvent)) {
+	if handler == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// publish calls every handler synchronously, in subscription order.
+func (b *eventBus) publish(ev LifecycleEvent) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(ev)
+	}
+}
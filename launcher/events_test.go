@@ -0,0 +1,174 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+// eventRecorder collects lifecycle events delivered to a handler.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []launcher.LifecycleEvent
+}
+
+func (r *eventRecorder) handle(ev launcher.LifecycleEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, ev)
+	r.mu.Unlock()
+}
+
+func (r *eventRecorder) forService(name string) []launcher.LifecycleEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []launcher.LifecycleEvent
+	for _, ev := range r.events {
+		if ev.Service == name {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+type transition struct{ from, to launcher.ServiceState }
+
+func transitions(events []launcher.LifecycleEvent) []transition {
+	res := make([]transition, len(events))
+	for i, ev := range events {
+		res[i] = transition{ev.From, ev.To}
+	}
+	return res
+}
+
+func TestLauncher_StateChangeEvents_Lifecycle(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		rec := new(eventRecorder)
+
+		ln := newTestLauncher(launcher.WithStateChangeHandler(rec.handle))
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("svc"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+		))
+
+		errCh := runLauncher(t, ln)
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+
+		events := rec.forService("svc")
+		want := []transition{
+			{launcher.ServiceStateIdle, launcher.ServiceStateStarting},
+			{launcher.ServiceStateStarting, launcher.ServiceStateRunning},
+			{launcher.ServiceStateRunning, launcher.ServiceStateStopping},
+			{launcher.ServiceStateStopping, launcher.ServiceStateStopped},
+		}
+		if got := transitions(events); !slices.Equal(got, want) {
+			t.Fatalf("transitions = %v; want %v", got, want)
+		}
+		for _, ev := range events {
+			if ev.Attempt != 1 || ev.Err != nil || ev.Reason != "" || ev.Time.IsZero() {
+				t.Errorf("unexpected event fields: %+v", ev)
+			}
+		}
+	})
+}
+
+func TestLauncher_StateChangeEvents_RestartPolicy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		rec := new(eventRecorder)
+		errBoom := errors.New("boom")
+		var calls atomic.Int32
+
+		ln := newTestLauncher()
+		ln.OnStateChange(rec.handle)
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("flaky"),
+			launcher.WithRestartPolicy(launcher.RestartPolicy{
+				Mode:       launcher.RestartOnFailure,
+				MaxRetries: 1,
+				Delay:      time.Second,
+			}),
+			launcher.WithStart(func(context.Context) error {
+				calls.Add(1)
+				return errBoom
+			}),
+			launcher.WithStop(noopStop),
+		))
+
+		if err := ln.Run(); !errors.Is(err, errBoom) {
+			t.Fatalf("Run error = %v; want %v", err, errBoom)
+		}
+
+		events := rec.forService("flaky")
+		want := []transition{
+			{launcher.ServiceStateIdle, launcher.ServiceStateStarting},
+			{launcher.ServiceStateStarting, launcher.ServiceStateRunning},
+			{launcher.ServiceStateRunning, launcher.ServiceStateStarting},
+			{launcher.ServiceStateStarting, launcher.ServiceStateRunning},
+			{launcher.ServiceStateRunning, launcher.ServiceStateFailed},
+		}
+		if got := transitions(events); !slices.Equal(got, want) {
+			t.Fatalf("transitions = %v; want %v", got, want)
+		}
+
+		restart := events[2]
+		if restart.Reason != launcher.EventReasonRestartPolicy || !errors.Is(restart.Err, errBoom) || restart.Attempt != 2 {
+			t.Errorf("restart event = %+v; want reason %q, err %v, attempt 2", restart, launcher.EventReasonRestartPolicy, errBoom)
+		}
+		failed := events[4]
+		if !errors.Is(failed.Err, errBoom) || failed.Attempt != 2 {
+			t.Errorf("failed event = %+v; want err %v, attempt 2", failed, errBoom)
+		}
+	})
+}
+
+func TestLauncher_StateChangeEvents_RestartRequested(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		rec := new(eventRecorder)
+
+		ln := newTestLauncher(launcher.WithStateChangeHandler(rec.handle))
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("pool"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+		))
+		errCh := runLauncher(t, ln)
+
+		before := len(rec.forService("pool"))
+		if err := ln.ServicesRunner().Restart(t.Context(), "pool"); err != nil {
+			t.Fatalf("Restart error: %v", err)
+		}
+
+		events := rec.forService("pool")[before:]
+		want := []transition{
+			{launcher.ServiceStateRunning, launcher.ServiceStateStopping},
+			{launcher.ServiceStateStopping, launcher.ServiceStateStopped},
+			{launcher.ServiceStateStopped, launcher.ServiceStateStarting},
+			{launcher.ServiceStateStarting, launcher.ServiceStateRunning},
+		}
+		if got := transitions(events); !slices.Equal(got, want) {
+			t.Fatalf("transitions = %v; want %v", got, want)
+		}
+		for _, ev := range events {
+			if ev.Reason != launcher.EventReasonRestartRequested {
+				t.Errorf("event %v -> %v reason = %q; want %q", ev.From, ev.To, ev.Reason, launcher.EventReasonRestartRequested)
+			}
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
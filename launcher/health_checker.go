@@ -0,0 +1,48 @@
+package launcher
+
+import (
+	"slices"
+
+	"github.com/tkcrm/mx/mxtypes"
+)
+
+// classifiedHealthChecker reports the health check classification set in the
+// service options. It passes the optional interfaces of the wrapped checker
+// through, so the ops health checker sees them as before.
+type classifiedHealthChecker struct {
+	mxtypes.HealthChecker
+	critical bool
+	tags     []string
+}
+
+func newClassifiedHealthChecker(opts *ServiceOptions) classifiedHealthChecker {
+	return classifiedHealthChecker{
+		HealthChecker: opts.HealthChecker,
+		critical:      opts.HealthCheckCritical,
+		tags:          slices.Clone(opts.HealthCheckTags),
+	}
+}
+
+func (c classifiedHealthChecker) HealthCheckCritical() bool { return c.critical }
+
+func (c classifiedHealthChecker) HealthCheckTags() []string { return c.tags }
+
+func (c classifiedHealthChecker) HealthDetails() map[string]any {
+	if impl, ok := c.HealthChecker.(mxtypes.HealthDetailer); ok {
+		return impl.HealthDetails()
+	}
+	return nil
+}
+
+func (c classifiedHealthChecker) HealthCheckPolicy() mxtypes.HealthCheckPolicy {
+	if impl, ok := c.HealthChecker.(mxtypes.HealthCheckPolicyProvider); ok {
+		return impl.HealthCheckPolicy()
+	}
+	return mxtypes.HealthCheckPolicy{}
+}
+
+var (
+	_ mxtypes.HealthCheckClassifier     = classifiedHealthChecker{}
+	_ mxtypes.HealthDetailer            = classifiedHealthChecker{}
+	_ mxtypes.HealthCheckPolicyProvider = classifiedHealthChecker{}
+)
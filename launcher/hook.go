@@ -0,0 +1,138 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+)
+
+// HookPolicy decides what a failing hook does to the phase it runs in.
+type HookPolicy int
+
+const (
+	// HookPolicyAbort fails the phase: a start hook aborts the start and a
+	// stop hook error is returned once the stop completed. It is the default.
+	HookPolicyAbort HookPolicy = iota
+	// HookPolicyLog only logs the error and lets the phase go on.
+	HookPolicyLog
+)
+
+// Hook is a named lifecycle hook with its own timeout.
+type Hook struct {
+	// Name identifies the hook in logs, errors and metrics.
+	Name string
+	// Fn is the hook. Its context is done once Timeout elapses or, for start
+	// hooks, once the launcher shuts down.
+	Fn func(ctx context.Context) error
+	// Timeout bounds the hook. A hook still running when it elapses fails
+	// with context.DeadlineExceeded and is no longer waited for. Zero means
+	// no limit.
+	Timeout time.Duration
+	// Policy decides whether a failure aborts the phase or is only logged.
+	Policy HookPolicy
+}
+
+// funcHook adapts a func() error hook.
+func funcHook(fn func() error) Hook {
+	return Hook{Fn: func(context.Context) error { return fn() }}
+}
+
+// phaseHooks returns the hooks of a phase: the funcs first, then the named
+// hooks.
+func phaseHooks(fns []func() error, hooks []Hook) []Hook {
+	res := make([]Hook, 0, len(fns)+len(hooks))
+	for _, fn := range fns {
+		res = append(res, funcHook(fn))
+	}
+	return append(res, hooks...)
+}
+
+// run runs the hook, giving up once its timeout elapses. A panic in the hook
+// is handled by call.
+func (h Hook) run(ctx context.Context, call func(fn func() error) error) error {
+	if h.Timeout <= 0 {
+		return call(func() error { return h.Fn(ctx) })
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- call(func() error { return h.Fn(ctx) }) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("hook timeout exceeded (%s): %w", h.Timeout, ctx.Err())
+		}
+		return ctx.Err()
+	}
+}
+
+// hookRun reports a finished run of a hook.
+type hookRun struct {
+	// service is empty for the launcher hooks
+	service  string
+	phase    Phase
+	hook     string
+	duration time.Duration
+	err      error
+}
+
+// hookRunner runs the hooks of one phase.
+type hookRunner struct {
+	logger logger.Logger
+	// base is the error reported for a failing hook, without Hook and Err.
+	base HookError
+	// observe receives every hook run; may be nil.
+	observe func(hookRun)
+	// panics decides whether a panicking hook fails or crashes the process.
+	panics PanicPolicy
+}
+
+// run runs hooks in order. Failures of hooks with HookPolicyLog are logged.
+// With failFast the first other failure is returned at once, otherwise all
+// hooks run and their failures are returned joined.
+func (r hookRunner) run(ctx context.Context, hooks []Hook, failFast bool) error {
+	var errs []error
+	for _, h := range hooks {
+		start := time.Now()
+		err := h.run(ctx, func(fn func() error) error {
+			hookErr := r.base
+			hookErr.Hook = h.Name
+			return r.panics.call(r.logger, hookErr.subject(), fn)
+		})
+		if r.observe != nil {
+			r.observe(hookRun{
+				service:  r.base.Service,
+				phase:    r.base.Phase,
+				hook:     h.Name,
+				duration: time.Since(start),
+				err:      err,
+			})
+		}
+		if err == nil {
+			continue
+		}
+
+		hookErr := r.base
+		hookErr.Hook = h.Name
+		hookErr.Err = err
+
+		if h.Policy == HookPolicyLog {
+			r.logger.Error(&hookErr)
+			continue
+		}
+		if failFast {
+			return &hookErr
+		}
+		errs = append(errs, &hookErr)
+	}
+
+	return errors.Join(errs...)
+}
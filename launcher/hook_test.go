@@ -0,0 +1,136 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+func TestLauncher_Hook_Timeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		hang := make(chan struct{})
+		defer close(hang)
+
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithBeforeStopHook(launcher.Hook{
+				Name:    "flush",
+				Timeout: 5 * time.Second,
+				// ignores its context and hangs until the test ends
+				Fn: func(context.Context) error { <-hang; return nil },
+			}),
+		)
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+		))
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+
+		start := time.Now()
+		ln.Stop()
+		err := <-errCh
+
+		if elapsed := time.Since(start); elapsed != 5*time.Second {
+			t.Errorf("shutdown took %s; want the 5s hook timeout", elapsed)
+		}
+
+		var hookErr *launcher.HookError
+		if !errors.As(err, &hookErr) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Run error = %v; want a *HookError exceeding its deadline", err)
+		}
+		if hookErr.Hook != "flush" || hookErr.Phase != launcher.PhaseBeforeStop {
+			t.Errorf("HookError = %+v; want hook flush in phase before_stop", hookErr)
+		}
+	})
+}
+
+func TestLauncher_Hook_PolicyLog(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var ran []string
+		record := func(name string, err error) launcher.Hook {
+			return launcher.Hook{
+				Name:   name,
+				Policy: launcher.HookPolicyLog,
+				Fn: func(context.Context) error {
+					ran = append(ran, name)
+					return err
+				},
+			}
+		}
+
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithBeforeStartHook(record("warm-cache", errors.New("cache down"))),
+			// func hooks run before the named hooks of the phase
+			launcher.WithBeforeStart(func() error { ran = append(ran, "legacy"); return nil }),
+			launcher.WithBeforeStartHook(record("announce", nil)),
+		)
+		svc := launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+		)
+		ln.ServicesRunner().Register(svc)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+
+		// the failing hook was only logged, so the services started
+		if svc.State() != launcher.ServiceStateRunning {
+			t.Fatalf("state = %v; want running", svc.State())
+		}
+		if want := []string{"legacy", "warm-cache", "announce"}; !slices.Equal(ran, want) {
+			t.Errorf("hooks ran %v; want %v", ran, want)
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error = %v; want nil", err)
+		}
+	})
+}
+
+func TestService_Hook_Context(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		svc := launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+			// waits for its dependency until the service is cancelled
+			launcher.WithServiceBeforeStartHook(launcher.Hook{
+				Name: "wait-db",
+				Fn: func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			}),
+		)
+		svc.Options().Context = ctx
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- svc.Start() }()
+		synctest.Wait()
+
+		cancel()
+
+		var hookErr *launcher.HookError
+		if err := <-errCh; !errors.As(err, &hookErr) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("Start error = %v; want a cancelled *HookError", err)
+		}
+		if hookErr.Service != "api" || hookErr.Hook != "wait-db" {
+			t.Errorf("HookError = %+v; want hook wait-db of service api", hookErr)
+		}
+	})
+}
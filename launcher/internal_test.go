@@ -2,10 +2,12 @@ package launcher
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/tkcrm/mx/logger"
+	"github.com/tkcrm/mx/mxtypes"
 )
 
 func quietLogger() logger.Logger {
@@ -66,7 +68,7 @@ func TestRestartPolicy_NextDelay(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			delay, allowed := tt.policy.nextDelay(tt.attempt)
+			delay, allowed := tt.policy.nextDelay(tt.attempt, 0)
 			if allowed != tt.wantAllow {
 				t.Fatalf("allowed = %v; want %v", allowed, tt.wantAllow)
 			}
@@ -120,6 +122,90 @@ func TestServicesRunner_HCAndStateProviders(t *testing.T) {
 	}
 }
 
+// The ops servers are left out of the probes, as they were when the probes
+// got a list of the services registered before them, while user services,
+// also those added at runtime, are reported.
+func TestOpsRegistry_ExcludesInternalServices(t *testing.T) {
+	runner := newServicesRunner(context.Background(), quietLogger())
+
+	ops := NewService(WithService(&internalHC{name: "ops-server"}))
+	ops.internal = true
+	runner.Register(NewService(WithService(&internalHC{name: "api"})), ops)
+	if err := runner.Add(NewService(WithService(&internalHC{name: "worker"}))); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+
+	registry := opsRegistry{runner}
+
+	var checkers, states []string
+	for _, hc := range registry.HealthCheckers() {
+		checkers = append(checkers, hc.Name())
+	}
+	for _, sp := range registry.StateProviders() {
+		states = append(states, sp.Name())
+	}
+
+	want := []string{"api", "worker"}
+	if !slices.Equal(checkers, want) || !slices.Equal(states, want) {
+		t.Errorf("health checkers = %v, state providers = %v; want %v", checkers, states, want)
+	}
+}
+
+// classifiedHC is a non-critical health checker with details and a policy.
+type classifiedHC struct{ internalHC }
+
+func (s *classifiedHC) HealthCheckCritical() bool     { return false }
+func (s *classifiedHC) HealthCheckTags() []string     { return []string{"cache"} }
+func (s *classifiedHC) HealthDetails() map[string]any { return map[string]any{"hits": 1} }
+func (s *classifiedHC) HealthCheckPolicy() mxtypes.HealthCheckPolicy {
+	return mxtypes.HealthCheckPolicy{FailureThreshold: 3}
+}
+
+func TestServicesRunner_HCClassification(t *testing.T) {
+	runner := newServicesRunner(context.Background(), quietLogger())
+	runner.Register(
+		NewService(WithService(&internalHC{name: "default"})),
+		NewService(WithService(&classifiedHC{internalHC{name: "from-interface"}})),
+		NewService(
+			WithService(&classifiedHC{internalHC{name: "overridden"}}),
+			WithHealthCheckCritical(true),
+			WithHealthCheckTags("external"),
+		),
+	)
+
+	tests := map[string]struct {
+		critical bool
+		tags     []string
+		details  bool
+	}{
+		"default":        {critical: true},
+		"from-interface": {critical: false, tags: []string{"cache"}, details: true},
+		"overridden":     {critical: true, tags: []string{"cache", "external"}, details: true},
+	}
+
+	for _, hc := range runner.hcServices() {
+		want := tests[hc.Name()]
+
+		c, ok := hc.(mxtypes.HealthCheckClassifier)
+		if !ok {
+			t.Fatalf("%s: checker does not implement HealthCheckClassifier", hc.Name())
+		}
+		if c.HealthCheckCritical() != want.critical || !slices.Equal(c.HealthCheckTags(), want.tags) {
+			t.Errorf("%s: critical=%v tags=%v; want %v %v", hc.Name(), c.HealthCheckCritical(), c.HealthCheckTags(), want.critical, want.tags)
+		}
+
+		// optional interfaces of the wrapped checker are passed through
+		d, _ := hc.(mxtypes.HealthDetailer)
+		p, _ := hc.(mxtypes.HealthCheckPolicyProvider)
+		if got := d != nil && d.HealthDetails() != nil; got != want.details {
+			t.Errorf("%s: has details = %v; want %v", hc.Name(), got, want.details)
+		}
+		if got := p != nil && p.HealthCheckPolicy().FailureThreshold == 3; got != want.details {
+			t.Errorf("%s: has policy = %v; want %v", hc.Name(), got, want.details)
+		}
+	}
+}
+
 func TestServicesRunner_RegisterValidationError_Skipped(t *testing.T) {
 	runner := newServicesRunner(context.Background(), quietLogger())
 
@@ -0,0 +1,116 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// jobServiceName is the name of the service running the func passed to RunJob.
+const jobServiceName = "job"
+
+// ExitCoder is implemented by errors carrying the process exit code of a job.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ExitError makes a job fail with a specific exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// NewExitError returns an error failing a job with code.
+func NewExitError(code int, err error) *ExitError {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+func (e *ExitError) ExitCode() int { return e.Code }
+
+// JobError is returned by Run when a one-shot service fails.
+type JobError struct {
+	Service string
+	// Code is the exit code carried by Err (see ExitCoder), or 1.
+	Code int
+	Err  error
+}
+
+func newJobError(svc *Service, err error) *JobError {
+	code := 1
+	var coder ExitCoder
+	if errors.As(err, &coder) && coder.ExitCode() != 0 {
+		code = coder.ExitCode()
+	}
+	return &JobError{Service: svc.Name(), Code: code, Err: err}
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("job [%s] failed with exit code %d: %s", e.Service, e.Code, e.Err)
+}
+
+func (e *JobError) Unwrap() error { return e.Err }
+
+func (e *JobError) ExitCode() int { return e.Code }
+
+// ExitCode returns the process exit code for an error returned by Run: 0 for
+// nil, the code of a failed job, and 1 for any other error.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var coder ExitCoder
+	if errors.As(err, &coder) && coder.ExitCode() != 0 {
+		return coder.ExitCode()
+	}
+	return 1
+}
+
+// jobResult reports the return of a one-shot service.
+type jobResult struct {
+	svc *Service
+	err error
+}
+
+// jobsDone reports whether every one-shot service has finished.
+func (l *launcher) jobsDone() bool {
+	for _, svc := range l.servicesRunner.Services() {
+		if !svc.Options().OneShot {
+			continue
+		}
+		switch svc.State() {
+		case ServiceStateIdle, ServiceStateStarting, ServiceStateRunning:
+			return false
+		}
+	}
+	return true
+}
+
+// RunJob runs fn as a one-shot service named "job" next to the registered
+// services and returns once it finished and everything was shut down. The
+// job context is done when ctx is done or the launcher shuts down.
+func (l *launcher) RunJob(ctx context.Context, fn func(ctx context.Context) error) error {
+	l.servicesRunner.Register(NewService(
+		WithServiceName(jobServiceName),
+		WithServiceOneShot(),
+		WithStart(func(svcCtx context.Context) error {
+			jobCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			defer context.AfterFunc(svcCtx, cancel)()
+
+			return fn(jobCtx)
+		}),
+		WithStop(func(context.Context) error { return nil }),
+	))
+
+	return l.Run()
+}
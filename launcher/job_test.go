@@ -0,0 +1,149 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+// jobLauncher returns a launcher with a long-running service whose stop is
+// recorded in stopped.
+func jobLauncher(stopped *bool) launcher.ILauncher {
+	ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+	ln.ServicesRunner().Register(launcher.NewService(
+		launcher.WithServiceName("db"),
+		launcher.WithStartupPriority(1),
+		launcher.WithStart(blockingStart),
+		launcher.WithStop(func(context.Context) error { *stopped = true; return nil }),
+	))
+	return ln
+}
+
+func TestLauncher_RunJob(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var stopped bool
+		ln := jobLauncher(&stopped)
+
+		ran := false
+		err := ln.RunJob(context.Background(), func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			ran = ctx.Err() == nil
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunJob error = %v; want nil", err)
+		}
+		if !ran {
+			t.Error("job did not run to completion")
+		}
+		if !stopped {
+			t.Error("services were not stopped after the job")
+		}
+		if code := launcher.ExitCode(err); code != 0 {
+			t.Errorf("ExitCode = %d; want 0", code)
+		}
+	})
+}
+
+func TestLauncher_RunJob_ExitCode(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var stopped bool
+		ln := jobLauncher(&stopped)
+
+		failure := errors.New("2 rows rejected")
+		err := ln.RunJob(context.Background(), func(context.Context) error {
+			return launcher.NewExitError(3, failure)
+		})
+
+		var jobErr *launcher.JobError
+		if !errors.As(err, &jobErr) || !errors.Is(err, failure) {
+			t.Fatalf("RunJob error = %v; want *JobError wrapping %v", err, failure)
+		}
+		if jobErr.Service != "job" || jobErr.Code != 3 {
+			t.Errorf("JobError = %+v; want job with exit code 3", jobErr)
+		}
+		if code := launcher.ExitCode(err); code != 3 {
+			t.Errorf("ExitCode = %d; want 3", code)
+		}
+		if !stopped {
+			t.Error("services were not stopped after the failed job")
+		}
+	})
+}
+
+func TestLauncher_RunJob_Context(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var stopped bool
+		ln := jobLauncher(&stopped)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		err := ln.RunJob(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.DeadlineExceeded) || launcher.ExitCode(err) != 1 {
+			t.Fatalf("RunJob error = %v; want the job deadline with exit code 1", err)
+		}
+	})
+}
+
+func TestLauncher_OneShot_WaitsForAllJobs(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var stopped bool
+		ln := jobLauncher(&stopped)
+
+		var (
+			mu       sync.Mutex
+			finished []string
+		)
+		job := func(name string, d time.Duration) *launcher.Service {
+			return launcher.NewService(
+				launcher.WithServiceName(name),
+				launcher.WithServiceOneShot(),
+				launcher.WithStart(func(context.Context) error {
+					time.Sleep(d)
+					mu.Lock()
+					finished = append(finished, name)
+					mu.Unlock()
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			)
+		}
+		ln.ServicesRunner().Register(job("migrate", time.Second), job("seed", 2*time.Second))
+
+		if err := ln.Run(); err != nil {
+			t.Fatalf("Run error = %v; want nil", err)
+		}
+		if len(finished) != 2 {
+			t.Errorf("finished jobs = %v; want migrate and seed", finished)
+		}
+		if !stopped {
+			t.Error("services were not stopped after the jobs")
+		}
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("boom"), 1},
+		{launcher.NewExitError(4, nil), 4},
+		{errors.Join(errors.New("stop failed"), launcher.NewExitError(2, errors.New("boom"))), 2},
+	}
+	for _, tt := range tests {
+		if got := launcher.ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d; want %d", tt.err, got, tt.want)
+		}
+	}
+}
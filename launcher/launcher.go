@@ -2,6 +2,7 @@
 package launcher
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -9,15 +10,17 @@ import (
 	"os/signal"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tkcrm/mx/launcher/ops"
-	"golang.org/x/sync/errgroup"
 )
 
 type ILauncher interface {
 	// Run launcher and all services
 	Run() error
+	// RunJob runs fn as a one-shot service and shuts down once it returned
+	RunJob(ctx context.Context, fn func(ctx context.Context) error) error
 	// Stop launcher and all services
 	Stop()
 	// ServicesRunner return services runner
@@ -33,6 +36,9 @@ type ILauncher interface {
 	AddAfterStartHooks(hook ...func() error)
 	// AddAfterStopHooks adds after stop hooks
 	AddAfterStopHooks(hook ...func() error)
+
+	// OnStateChange subscribes handler to the state transitions and reloads of all services
+	OnStateChange(handler func(LifecycleEvent))
 }
 
 type launcher struct {
@@ -41,12 +47,36 @@ type launcher struct {
 	cancelFn context.CancelFunc
 
 	servicesRunner *servicesRunner
+
+	events *eventBus
+
+	// metrics is nil unless the ops metrics server is enabled
+	metrics *lifecycleMetrics
+	// startup is nil unless the ops health checker is enabled
+	startup *startupTracker
+
+	// draining is set once a shutdown signal was received
+	draining atomic.Bool
+
+	// signalMu serializes the handling of non-shutdown signals
+	signalMu sync.Mutex
+
+	// forced is set once the shutdown was forced
+	forced atomic.Bool
+
+	// budget is nil unless a restart budget is configured
+	budget *restartBudget
 }
 
 // New creates a new launcher.
 func New(opts ...Option) ILauncher {
 	l := &launcher{
-		opts: newOptions(opts...),
+		opts:   newOptions(opts...),
+		events: new(eventBus),
+	}
+
+	for _, fn := range l.opts.StateChangeHandlers {
+		l.events.subscribe(fn)
 	}
 
 	ctx, cancel := context.WithCancel(l.opts.Context)
@@ -54,6 +84,12 @@ func New(opts ...Option) ILauncher {
 	l.cancelFn = cancel
 
 	l.servicesRunner = newServicesRunner(l.opts.Context, l.opts.logger)
+	l.servicesRunner.publish = l.events.publish
+	l.servicesRunner.observeHook = l.observeHook
+	l.servicesRunner.panics = l.opts.PanicPolicy
+
+	l.budget = newRestartBudget(l.opts.RestartBudget, l.opts.logger)
+	l.servicesRunner.budget = l.budget
 
 	return l
 }
@@ -62,28 +98,57 @@ func New(opts ...Option) ILauncher {
 func (l *launcher) Run() error { //nolint:cyclop
 	// register ops services
 	if l.opts.OpsConfig.Enabled {
+		if l.opts.OpsConfig.Metrics.Enabled {
+			metrics, err := newLifecycleMetrics(l.opts.OpsConfig.Metrics.GetRegisterer(), l.opts.Name, l.opts.Version)
+			if err != nil {
+				return fmt.Errorf("failed to register launcher metrics: %w", err)
+			}
+			l.metrics = metrics
+			l.events.subscribe(l.metrics.observe)
+		}
 		if l.opts.OpsConfig.Healthy.Enabled {
-			l.opts.OpsConfig.Healthy.AddServicesList(l.servicesRunner.hcServices())
-			l.opts.OpsConfig.Healthy.AddStateList(l.servicesRunner.stateProviders())
+			l.opts.OpsConfig.Healthy.SetServicesRegistry(opsRegistry{l.servicesRunner})
+			l.startup = newStartupTracker(l.servicesRunner)
+			l.events.subscribe(l.startup.observe)
+			l.opts.OpsConfig.Healthy.SetStartupTracker(l.startup)
+			l.opts.OpsConfig.Healthy.SetDrainReporter(l)
+			l.opts.OpsConfig.Healthy.SetLivenessReporter(l)
+			l.opts.OpsConfig.Healthy.SetObserver(l)
+		}
+		if l.opts.OpsConfig.Admin.Enabled {
+			l.opts.OpsConfig.Admin.SetController(l)
 		}
 		opsSvcs := ops.New(l.opts.logger, l.opts.OpsConfig)
 		svcs := make([]*Service, len(opsSvcs))
 		for i := range opsSvcs {
 			svcs[i] = NewService(WithService(opsSvcs[i]))
+			svcs[i].internal = true
 		}
 		l.servicesRunner.Register(svcs...)
 	}
 
+	// resolve service dependencies before anything starts
+	graph, err := newDependencyGraph(l.servicesRunner.Services())
+	if err != nil {
+		return err
+	}
+
+	l.metrics.init(l.servicesRunner.Services())
+	l.startup.init(l.servicesRunner.Services())
+
 	// before start
-	for _, fn := range l.opts.BeforeStart {
-		if err := fn(); err != nil {
-			return err
-		}
+	if err := l.hooks(PhaseBeforeStart).run(l.opts.Context, phaseHooks(l.opts.BeforeStart, l.opts.BeforeStartHooks), true); err != nil {
+		return err
 	}
 
-	// group services by startup priority
+	// group services without declared dependencies by startup priority
 	groups := make(map[int][]*Service)
+	var dependent []*Service
 	for _, svc := range l.servicesRunner.Services() {
+		if len(graph.dependencies(svc)) > 0 {
+			dependent = append(dependent, svc)
+			continue
+		}
 		p := svc.Options().StartupPriority
 		groups[p] = append(groups[p], svc)
 	}
@@ -97,111 +162,209 @@ func (l *launcher) Run() error { //nolint:cyclop
 	}
 	slices.Sort(priorities)
 
-	errChan := make(chan error, len(l.servicesRunner.Services()))
+	fails := newFailures()
 	graceWait := new(sync.WaitGroup)
 
-	startSvc := func(svc *Service) {
+	// jobDone receives the results of the one-shot services
+	jobDone := make(chan jobResult, len(l.servicesRunner.Services()))
+
+	// startSvc starts svc. The failure of an on-demand restart before the
+	// service became ready is returned by Restart and does not shut the
+	// launcher down.
+	startSvc := func(svc *Service, onDemand bool) {
+		graceWait.Go(func() {
+			err := svc.Start()
+			if svc.Options().OneShot {
+				select {
+				case jobDone <- jobResult{svc: svc, err: err}:
+				case <-l.opts.Context.Done():
+				}
+				return
+			}
+			if err != nil && (!onDemand || svc.becameReady()) {
+				fails.add(err)
+			}
+		})
+	}
+
+	// launch starts svc as soon as all of its dependencies are ready,
+	// regardless of startup priority
+	launch := func(svc *Service, deps []*Service, onDemand bool) {
+		l.metrics.launch(svc)
+		l.startup.launch(svc)
+
+		if len(deps) == 0 {
+			startSvc(svc, onDemand)
+			return
+		}
+
 		graceWait.Go(func() {
-			if err := svc.Start(); err != nil {
-				errChan <- fmt.Errorf("failed to start service [%s]: %w", svc.Name(), err)
+			for _, dep := range deps {
+				select {
+				case <-dep.Ready():
+				case <-l.opts.Context.Done():
+					return
+				}
+
+				// the dependency failed or was skipped; its own error aborts the launch
+				if dep.State() != ServiceStateRunning {
+					svc.closeReady()
+					return
+				}
 			}
+
+			startSvc(svc, onDemand)
 		})
 	}
 
+	// services added at runtime are launched the same way
+	l.servicesRunner.setLaunch(launch)
+
+	// waitServices stops launching services added at runtime and waits for
+	// all started services to return
+	waitServices := func() {
+		l.servicesRunner.setLaunch(nil)
+		graceWait.Wait()
+	}
+
+	for _, svc := range dependent {
+		launch(svc, graph.dependencies(svc), false)
+	}
+
 	// start priority groups sequentially; within each group — concurrently
 	for _, p := range priorities {
 		group := groups[p]
 
 		for _, svc := range group {
-			startSvc(svc)
+			startSvc(svc, false)
 		}
 
 		// wait for ALL services in this group to become ready
 		for _, svc := range group {
 			select {
 			case <-svc.Ready():
-			case err := <-errChan:
+			case <-fails.failed():
 				l.cancelFn()
-				graceWait.Wait()
-				return err
+				waitServices()
+				return fails.join(nil)
 			case <-l.opts.Context.Done():
-				graceWait.Wait()
+				waitServices()
 				return l.opts.Context.Err()
 			}
 		}
 
 		// Yield to let any immediate service failures propagate.
 		// A service that fails synchronously closes readyCh (via deferred Start)
-		// before the error reaches errChan. This sleep gives startSvc goroutines
+		// before the error is recorded. This sleep gives startSvc goroutines
 		// time to deliver the error after Start() returns.
 		time.Sleep(time.Nanosecond)
 		select {
-		case err := <-errChan:
+		case <-fails.failed():
 			l.cancelFn()
-			graceWait.Wait()
-			return err
+			waitServices()
+			return fails.join(nil)
 		default:
 		}
 	}
 
 	// start priority-0 services — all concurrently
 	for _, svc := range groups[0] {
-		startSvc(svc)
+		startSvc(svc, false)
 	}
+	l.startup.launchedAll()
 
 	if l.opts.AppStartStopLog {
 		l.opts.logger.Infoln("app", l.opts.Name, "was started")
 	}
 
 	// after start
-	for _, fn := range l.opts.AfterStart {
-		if err := fn(); err != nil {
-			l.cancelFn()
-			graceWait.Wait()
-			return err
-		}
+	if err := l.hooks(PhaseAfterStart).run(l.opts.Context, phaseHooks(l.opts.AfterStart, l.opts.AfterStartHooks), true); err != nil {
+		l.cancelFn()
+		waitServices()
+		return fails.join(err)
 	}
 
 	ch := make(chan os.Signal, 1)
-	if l.opts.Signal {
-		signal.Notify(ch, ShutdownSiganl()...)
+	if sigs := l.watchedSignals(); l.opts.Signal && len(sigs) > 0 {
+		notifySignals(ch, sigs...)
 		defer signal.Stop(ch)
 	}
 
-	var forceExitCancel context.CancelFunc
+	// forced receives the error of a forced shutdown whose exit func returned
+	forced := make(chan error, 1)
+	forceCtx, forceCancel := context.WithCancel(context.Background())
+	defer forceCancel()
 
-	select {
-	// wait on services error
-	case err := <-errChan:
-		l.cancelFn()
-		graceWait.Wait()
-		return err
-	// wait on kill signal
-	case <-ch:
-		l.cancelFn()
-		l.opts.logger.Infoln("graceful shutdown started, send signal again to force exit")
-		if l.opts.Signal {
-			var forceCtx context.Context
-			forceCtx, forceExitCancel = context.WithCancel(context.Background())
+	drain := false
+	var jobErr error
+
+wait:
+	for {
+		select {
+		// wait on one-shot services
+		case job := <-jobDone:
+			if job.err != nil {
+				jobErr = newJobError(job.svc, job.err)
+				break wait
+			}
+			if l.jobsDone() {
+				break wait
+			}
+		// wait on services error
+		case <-fails.failed():
+			// the launcher is already shutting down on request, the failure
+			// is returned by shutdown
+			if l.opts.Context.Err() != nil {
+				break wait
+			}
+			l.cancelFn()
+			waitServices()
+			return fails.join(nil)
+		// wait on signal
+		case sig := <-ch:
+			if !l.isShutdownSignal(sig) {
+				go l.handleSignal(sig)
+				continue
+			}
+
+			l.opts.logger.Infoln("graceful shutdown started, send signal again to force exit")
 			go func() {
-				select {
-				case <-ch:
-					l.opts.logger.Infoln("received second signal, forcing exit")
-					os.Exit(1)
-				case <-forceCtx.Done():
+				for {
+					select {
+					case sig := <-ch:
+						if !l.isShutdownSignal(sig) {
+							continue
+						}
+						l.forceShutdown("received second signal", forced)
+						return
+					case <-forceCtx.Done():
+						return
+					}
 				}
 			}()
+			drain = true
+			break wait
+		// wait on context cancel
+		case <-l.opts.Context.Done():
+			break wait
 		}
-	// wait on context cancel
-	case <-l.opts.Context.Done():
 	}
 
-	if forceExitCancel != nil {
-		defer forceExitCancel()
-	}
+	done := make(chan error, 1)
+	go func() { done <- l.shutdown(drain, waitServices, forced, fails) }()
 
-	graceWait.Wait()
+	select {
+	case err := <-done:
+		return errors.Join(jobErr, err)
+	case err := <-forced:
+		return err
+	}
+}
 
+// shutdown drains if requested, waits for the services to return, and stops
+// them between the before and after stop hooks. The returned error joins the
+// failures of the services with those of the stop.
+func (l *launcher) shutdown(drain bool, waitServices func(), forced chan<- error, fails *failures) error {
 	var stopCtx context.Context
 	var stopCtxCancel context.CancelFunc
 	if l.opts.GlobalShutdownTimeout > 0 {
@@ -211,76 +374,184 @@ func (l *launcher) Run() error { //nolint:cyclop
 	}
 	defer stopCtxCancel()
 
-	// enforce global shutdown timeout
+	// enforce global shutdown timeout, the drain included
 	go func() {
 		<-stopCtx.Done()
 		if stopCtx.Err() == context.DeadlineExceeded {
-			l.opts.logger.Infoln("global shutdown timeout exceeded, forcing exit")
-			os.Exit(1)
+			l.forceShutdown("global shutdown timeout exceeded", forced)
 		}
 	}()
 
-	var stopErr error
+	if drain {
+		l.drain()
+	}
+	l.cancelFn()
+
+	waitServices()
+	failed := fails.join(nil)
 
 	// before stop
-	for _, fn := range l.opts.BeforeStop {
-		if err := fn(); err != nil {
-			stopErr = errors.Join(stopErr, err)
+	stopErr := l.hooks(PhaseBeforeStop).run(stopCtx, phaseHooks(l.opts.BeforeStop, l.opts.BeforeStopHooks), false)
+
+	// stop services
+	stopErr = errors.Join(stopErr, l.stopServices())
+
+	if l.opts.AppStartStopLog {
+		l.opts.logger.Infoln("app", l.opts.Name, "was stopped")
+	}
+
+	// after stop
+	stopErr = errors.Join(stopErr, l.hooks(PhaseAfterStop).run(stopCtx, phaseHooks(l.opts.AfterStop, l.opts.AfterStopHooks), false))
+
+	return errors.Join(failed, stopErr)
+}
+
+// stopServices stops all services according to RunnerServicesSequence. A
+// service is never stopped before the services that depend on it. The stop
+// errors are logged and returned joined.
+func (l *launcher) stopServices() error {
+	services := l.servicesRunner.Services()
+
+	// the graph was validated by Run and kept valid by Add and Remove
+	graph, err := newDependencyGraph(services)
+	if err != nil {
+		l.opts.logger.Errorf("failed to resolve service dependencies on stop: %s", err)
+		graph = &dependencyGraph{}
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	stop := func(svc *Service) {
+		if err := svc.Stop(); err != nil {
+			l.opts.logger.Error(err)
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
 		}
 	}
 
-	// stop services
 	switch l.opts.RunnerServicesSequence {
 	case RunnerServicesSequenceNone:
-		{
-			g := new(errgroup.Group)
-			for i := range l.servicesRunner.Services() {
-				svc := l.servicesRunner.Services()[i]
-				g.Go(func() error {
-					if err := svc.Stop(); err != nil {
-						l.opts.logger.Errorf("failed to stop service [%s] error: %s", svc.Name(), err)
-					}
-					return nil
-				})
-			}
-
-			// wait for all stop goroutines to finish; per-service errors are
-			// already logged above, so Wait never reports one.
-			_ = g.Wait()
-		}
+		stopConcurrently(services, graph, nil, stop)
+	case RunnerServicesSequenceReversePriority:
+		stopConcurrently(services, graph, stopRanks(services, graph), stop)
 	case RunnerServicesSequenceFifo:
-		{
-			for _, svc := range l.servicesRunner.Services() {
-				if err := svc.Stop(); err != nil {
-					l.opts.logger.Errorf("failed to stop service [%s] error: %s", svc.Name(), err)
-				}
-			}
+		for _, svc := range graph.stopOrder(services) {
+			stop(svc)
 		}
 	case RunnerServicesSequenceLifo:
-		{
-			reverted := make([]*Service, len(l.servicesRunner.Services()))
-			copy(reverted, l.servicesRunner.Services())
-			slices.Reverse(reverted)
-			for _, svc := range reverted {
-				if err := svc.Stop(); err != nil {
-					l.opts.logger.Errorf("failed to stop service [%s] error: %s", svc.Name(), err)
+		reverted := slices.Clone(services)
+		slices.Reverse(reverted)
+		for _, svc := range graph.stopOrder(reverted) {
+			stop(svc)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopConcurrently stops services concurrently, each once the services that
+// depend on it and all services of a lower rank are stopped. A nil ranks map
+// puts all services in the same rank.
+func stopConcurrently(services []*Service, graph *dependencyGraph, ranks map[*Service]int, stop func(*Service)) {
+	stopped := make(map[*Service]chan struct{}, len(services))
+	for _, svc := range services {
+		stopped[svc] = make(chan struct{})
+	}
+
+	wg := new(sync.WaitGroup)
+	for _, svc := range services {
+		wg.Go(func() {
+			defer close(stopped[svc])
+			for _, d := range graph.dependents[svc] {
+				if ch, ok := stopped[d]; ok {
+					<-ch
+				}
+			}
+			for _, other := range services {
+				if ranks[other] < ranks[svc] {
+					<-stopped[other]
 				}
 			}
+			stop(svc)
+		})
+	}
+	wg.Wait()
+}
+
+// stopRanks assigns every service its stop group for
+// RunnerServicesSequenceReversePriority: priority 0 stops first, then the
+// groups in descending priority. A service is never ranked before the
+// services that depend on it.
+func stopRanks(services []*Service, graph *dependencyGraph) map[*Service]int {
+	var priorities []int
+	for _, svc := range services {
+		if p := svc.Options().stopPriority(); !slices.Contains(priorities, p) {
+			priorities = append(priorities, p)
+		}
+	}
+	slices.SortFunc(priorities, func(a, b int) int {
+		switch {
+		case a == 0:
+			return -1
+		case b == 0:
+			return 1
+		}
+		return cmp.Compare(b, a)
+	})
+
+	ranks := make(map[*Service]int, len(services))
+	var rank func(svc *Service) int
+	rank = func(svc *Service) int {
+		if r, ok := ranks[svc]; ok {
+			return r
+		}
+		r := slices.Index(priorities, svc.Options().stopPriority())
+		for _, d := range graph.dependents[svc] {
+			if slices.Contains(services, d) {
+				r = max(r, rank(d))
+			}
 		}
+		ranks[svc] = r
+		return r
+	}
+	for _, svc := range services {
+		rank(svc)
 	}
 
-	if l.opts.AppStartStopLog {
-		l.opts.logger.Infoln("app", l.opts.Name, "was stopped")
+	return ranks
+}
+
+// hooks returns the runner of the launcher hooks of phase.
+func (l *launcher) hooks(phase Phase) hookRunner {
+	return hookRunner{
+		logger:  l.opts.logger,
+		base:    HookError{Phase: phase},
+		observe: l.observeHook,
+		panics:  l.opts.PanicPolicy,
 	}
+}
 
-	// after stop
-	for _, fn := range l.opts.AfterStop {
-		if err := fn(); err != nil {
-			stopErr = errors.Join(stopErr, err)
+// observeHook records a run of a launcher or service hook in the metrics.
+func (l *launcher) observeHook(run hookRun) { l.metrics.observeHook(run) }
+
+// ObserveHealthCheck records a health check in the metrics. It implements
+// ops.HealthCheckObserver.
+func (l *launcher) ObserveHealthCheck(name string, duration time.Duration, err error) {
+	l.metrics.ObserveHealthCheck(name, duration, err)
+}
+
+// ObserveHealthStatus counts a check reported unhealthy towards the
+// RestartPolicy.UnhealthyThreshold of its service. It implements
+// ops.HealthStatusObserver.
+func (l *launcher) ObserveHealthStatus(name string, err error) {
+	for _, svc := range l.servicesRunner.Services() {
+		if hc := svc.Options().HealthChecker; hc != nil && !svc.internal && hc.Name() == name {
+			svc.observeHealth(err)
 		}
 	}
-
-	return stopErr
 }
 
 // Stop stops launcher and all services.
@@ -331,3 +602,12 @@ func (l *launcher) AddAfterStopHooks(hook ...func() error) {
 		l.opts.AfterStop = append(l.opts.AfterStop, fn)
 	}
 }
+
+// OnStateChange subscribes handler to the state transitions of all services
+// and to their reloads (see EventReasonReload).
+// Handlers are called synchronously, in order, from the goroutine changing the
+// state, so they must return quickly and must not control services themselves;
+// hand slow work off to another goroutine.
+func (l *launcher) OnStateChange(handler func(LifecycleEvent)) {
+	l.events.subscribe(handler)
+}
@@ -857,6 +857,72 @@ func TestLauncher_StopSequence_Lifo(t *testing.T) {
 	})
 }
 
+func TestLauncher_StopSequence_ReversePriority(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var mu sync.Mutex
+		stoppedAt := make(map[string]time.Duration)
+
+		ln := newTestLauncher(
+			launcher.WithRunnerServicesSequence(launcher.RunnerServicesSequenceReversePriority),
+		)
+
+		var start time.Time
+		register := func(name string, opts ...launcher.ServiceOption) {
+			ln.ServicesRunner().Register(launcher.NewService(append([]launcher.ServiceOption{
+				launcher.WithServiceName(name),
+				launcher.WithStart(blockingStart),
+				launcher.WithStop(func(context.Context) error {
+					mu.Lock()
+					stoppedAt[name] = time.Since(start)
+					mu.Unlock()
+					time.Sleep(time.Second)
+					return nil
+				}),
+			}, opts...)...))
+		}
+
+		register("db", launcher.WithStartupPriority(1))
+		register("cache", launcher.WithStartupPriority(2))
+		register("api")
+		register("worker")
+		// stops with the db group instead of the default group
+		register("exporter", launcher.WithStopPriority(1))
+		// would stop in the default group, but is held back to the group of
+		// its dependent and stops right after it
+		register("queue", launcher.WithStopPriority(0), launcher.WithStartupPriority(3))
+		register("consumer", launcher.WithStartupPriority(2), launcher.WithDependsOn("queue"))
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+
+		// the default group starts last
+		api, _ := ln.ServicesRunner().Get("api")
+		<-api.Ready()
+		synctest.Wait()
+
+		start = time.Now()
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+
+		want := map[string]time.Duration{
+			"api":      0,
+			"worker":   0,
+			"cache":    time.Second,
+			"consumer": time.Second,
+			"queue":    2 * time.Second,
+			"db":       3 * time.Second,
+			"exporter": 3 * time.Second,
+		}
+		for name, at := range want {
+			if stoppedAt[name] != at {
+				t.Errorf("service %s stopped after %s; want %s", name, stoppedAt[name], at)
+			}
+		}
+	})
+}
+
 func TestLauncher_StopSequence_None_Parallel(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		var stopCount atomic.Int32
@@ -0,0 +1,243 @@
+package launcher
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tkcrm/mx/launcher/ops"
+)
+
+// allServiceStates lists every state exported by the mx_service_state gauge.
+var allServiceStates = []ServiceState{
+	ServiceStateIdle,
+	ServiceStateStarting,
+	ServiceStateRunning,
+	ServiceStateStopping,
+	ServiceStateStopped,
+	ServiceStateFailed,
+}
+
+// lifecycleMetrics exports the service lifecycle and the health check results
+// as Prometheus metrics. It is fed by the launcher event bus and by the ops
+// health checker. A nil *lifecycleMetrics is valid and records nothing.
+type lifecycleMetrics struct {
+	state            *prometheus.GaugeVec
+	restarts         *prometheus.CounterVec
+	startupDuration  *prometheus.HistogramVec
+	shutdownDuration *prometheus.HistogramVec
+	readinessLatency *prometheus.GaugeVec
+	checkHealthy     *prometheus.GaugeVec
+	checkDuration    *prometheus.HistogramVec
+	hookDuration     *prometheus.HistogramVec
+	hookFailures     *prometheus.CounterVec
+
+	mu sync.Mutex
+	// launched, startingAt and stoppingAt hold the start time of the phase
+	// each service is currently in.
+	launched   map[string]time.Time
+	startingAt map[string]time.Time
+	stoppingAt map[string]time.Time
+}
+
+// newLifecycleMetrics creates the launcher metrics and registers them with reg,
+// labelled with the app name and version. Metrics already registered by
+// another launcher of the same app are reused.
+func newLifecycleMetrics(reg prometheus.Registerer, name, version string) (*lifecycleMetrics, error) {
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"app": name, "version": version}, reg)
+
+	m := &lifecycleMetrics{
+		launched:   make(map[string]time.Time),
+		startingAt: make(map[string]time.Time),
+		stoppingAt: make(map[string]time.Time),
+	}
+
+	var err error
+	if m.state, err = register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mx_service_state",
+		Help: "Current state of the service: 1 for the current state, 0 for the others.",
+	}, []string{"service", "state"})); err != nil {
+		return nil, err
+	}
+	if m.restarts, err = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mx_service_restarts_total",
+		Help: "Number of service restarts, by restart policy or on demand.",
+	}, []string{"service"})); err != nil {
+		return nil, err
+	}
+	if m.startupDuration, err = register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mx_service_startup_duration_seconds",
+		Help:    "Time from the start of the service to it becoming ready.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service"})); err != nil {
+		return nil, err
+	}
+	if m.shutdownDuration, err = register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mx_service_shutdown_duration_seconds",
+		Help:    "Time the service took to stop.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service"})); err != nil {
+		return nil, err
+	}
+	if m.readinessLatency, err = register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mx_service_readiness_latency_seconds",
+		Help: "Time from the launch of the service, including waiting for its dependencies, to it becoming ready.",
+	}, []string{"service"})); err != nil {
+		return nil, err
+	}
+	if m.checkHealthy, err = register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mx_health_check_healthy",
+		Help: "Result of the last health check: 1 if healthy, 0 otherwise.",
+	}, []string{"check"})); err != nil {
+		return nil, err
+	}
+	if m.checkDuration, err = register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mx_health_check_duration_seconds",
+		Help:    "Duration of health checks.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"check"})); err != nil {
+		return nil, err
+	}
+	if m.hookDuration, err = register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mx_hook_duration_seconds",
+		Help:    "Duration of named lifecycle hooks.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "phase", "hook"})); err != nil {
+		return nil, err
+	}
+	if m.hookFailures, err = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mx_hook_failures_total",
+		Help: "Number of failed runs of named lifecycle hooks.",
+	}, []string{"service", "phase", "hook"})); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// register registers c with reg, or returns the equal collector registered before.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+// init exports the current state of the services registered before Run and
+// marks them as launched.
+func (m *lifecycleMetrics) init(services []*Service) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, svc := range services {
+		m.setStateLocked(svc.Name(), svc.State())
+		m.launched[svc.Name()] = now
+	}
+}
+
+// launch marks the moment svc is handed to the launcher to be started; the
+// readiness latency is measured from it.
+func (m *lifecycleMetrics) launch(svc *Service) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.launched[svc.Name()] = time.Now()
+}
+
+// observe updates the metrics on a service state transition.
+func (m *lifecycleMetrics) observe(ev LifecycleEvent) {
+	if m == nil {
+		return
+	}
+
+	// reloads are reported without a transition
+	if ev.From == ev.To {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setStateLocked(ev.Service, ev.To)
+
+	switch ev.To {
+	case ServiceStateStarting:
+		// the first start has no reason, every restart has one
+		if ev.Reason != "" {
+			m.restarts.WithLabelValues(ev.Service).Inc()
+		}
+		m.startingAt[ev.Service] = ev.Time
+	case ServiceStateRunning:
+		if at, ok := m.startingAt[ev.Service]; ok {
+			m.startupDuration.WithLabelValues(ev.Service).Observe(ev.Time.Sub(at).Seconds())
+			delete(m.startingAt, ev.Service)
+		}
+		if at, ok := m.launched[ev.Service]; ok {
+			m.readinessLatency.WithLabelValues(ev.Service).Set(ev.Time.Sub(at).Seconds())
+			delete(m.launched, ev.Service)
+		}
+	case ServiceStateStopping:
+		m.stoppingAt[ev.Service] = ev.Time
+	case ServiceStateStopped, ServiceStateFailed:
+		if at, ok := m.stoppingAt[ev.Service]; ok {
+			m.shutdownDuration.WithLabelValues(ev.Service).Observe(ev.Time.Sub(at).Seconds())
+			delete(m.stoppingAt, ev.Service)
+		}
+		delete(m.startingAt, ev.Service)
+		delete(m.launched, ev.Service)
+	}
+}
+
+// observeHook records a run of a named hook. The service is empty for the
+// launcher hooks.
+func (m *lifecycleMetrics) observeHook(run hookRun) {
+	if m == nil || run.hook == "" {
+		return
+	}
+
+	m.hookDuration.WithLabelValues(run.service, string(run.phase), run.hook).Observe(run.duration.Seconds())
+	if run.err != nil {
+		m.hookFailures.WithLabelValues(run.service, string(run.phase), run.hook).Inc()
+	}
+}
+
+func (m *lifecycleMetrics) setStateLocked(service string, current ServiceState) {
+	for _, state := range allServiceStates {
+		v := 0.0
+		if state == current {
+			v = 1
+		}
+		m.state.WithLabelValues(service, state.String()).Set(v)
+	}
+}
+
+// ObserveHealthCheck implements ops.HealthCheckObserver.
+func (m *lifecycleMetrics) ObserveHealthCheck(name string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	healthy := 1.0
+	if err != nil {
+		healthy = 0
+	}
+	m.checkHealthy.WithLabelValues(name).Set(healthy)
+	m.checkDuration.WithLabelValues(name).Observe(duration.Seconds())
+}
+
+var _ ops.HealthCheckObserver = (*lifecycleMetrics)(nil)
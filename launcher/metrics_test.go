@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tkcrm/mx/launcher/ops"
+	"github.com/tkcrm/mx/logger"
+)
+
+// findMetric returns the metric of the named family whose labels include all
+// of the given ones, or nil.
+func findMetric(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range mf.GetMetric() {
+			got := make(map[string]string, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				got[lp.GetName()] = lp.GetValue()
+			}
+			for k, v := range labels {
+				if got[k] != v {
+					continue next
+				}
+			}
+			return m
+		}
+	}
+	return nil
+}
+
+func TestLifecycleMetrics_Observe(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := newLifecycleMetrics(reg, "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("newLifecycleMetrics: %v", err)
+	}
+
+	svc := NewService(WithServiceName("svc"))
+	m.init([]*Service{svc})
+
+	now := time.Now()
+	events := []LifecycleEvent{
+		{Service: "svc", From: ServiceStateIdle, To: ServiceStateStarting, Time: now},
+		{Service: "svc", From: ServiceStateStarting, To: ServiceStateRunning, Time: now.Add(2 * time.Second)},
+		{Service: "svc", From: ServiceStateRunning, To: ServiceStateStarting, Reason: EventReasonRestartPolicy, Time: now.Add(3 * time.Second)},
+		{Service: "svc", From: ServiceStateStarting, To: ServiceStateRunning, Time: now.Add(4 * time.Second)},
+		{Service: "svc", From: ServiceStateRunning, To: ServiceStateStopping, Time: now.Add(5 * time.Second)},
+		{Service: "svc", From: ServiceStateStopping, To: ServiceStateStopped, Time: now.Add(6 * time.Second)},
+	}
+	for _, ev := range events {
+		m.observe(ev)
+	}
+
+	for _, state := range allServiceStates {
+		want := 0.0
+		if state == ServiceStateStopped {
+			want = 1
+		}
+		if got := testutil.ToFloat64(m.state.WithLabelValues("svc", state.String())); got != want {
+			t.Errorf("mx_service_state{state=%q} = %v; want %v", state, got, want)
+		}
+	}
+
+	if got := testutil.ToFloat64(m.restarts.WithLabelValues("svc")); got != 1 {
+		t.Errorf("mx_service_restarts_total = %v; want 1", got)
+	}
+
+	startup := findMetric(t, reg, "mx_service_startup_duration_seconds", map[string]string{"service": "svc"})
+	if startup == nil || startup.GetHistogram().GetSampleCount() != 2 || startup.GetHistogram().GetSampleSum() != 3 {
+		t.Errorf("mx_service_startup_duration_seconds = %v; want 2 samples summing to 3s", startup)
+	}
+
+	shutdown := findMetric(t, reg, "mx_service_shutdown_duration_seconds", map[string]string{"service": "svc"})
+	if shutdown == nil || shutdown.GetHistogram().GetSampleCount() != 1 || shutdown.GetHistogram().GetSampleSum() != 1 {
+		t.Errorf("mx_service_shutdown_duration_seconds = %v; want 1 sample of 1s", shutdown)
+	}
+
+	if findMetric(t, reg, "mx_service_readiness_latency_seconds", map[string]string{"service": "svc"}) == nil {
+		t.Error("mx_service_readiness_latency_seconds was not set")
+	}
+
+	labels := map[string]string{"app": "app", "version": "1.0.0", "service": "svc", "state": "stopped"}
+	if findMetric(t, reg, "mx_service_state", labels) == nil {
+		t.Errorf("mx_service_state has no series with labels %v", labels)
+	}
+}
+
+func TestLifecycleMetrics_ObserveHealthCheck(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := newLifecycleMetrics(reg, "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("newLifecycleMetrics: %v", err)
+	}
+
+	var observer ops.HealthCheckObserver = m
+	observer.ObserveHealthCheck("db", 500*time.Millisecond, nil)
+	if got := testutil.ToFloat64(m.checkHealthy.WithLabelValues("db")); got != 1 {
+		t.Errorf("mx_health_check_healthy = %v; want 1", got)
+	}
+
+	observer.ObserveHealthCheck("db", 250*time.Millisecond, errors.New("down"))
+	if got := testutil.ToFloat64(m.checkHealthy.WithLabelValues("db")); got != 0 {
+		t.Errorf("mx_health_check_healthy = %v; want 0", got)
+	}
+
+	duration := findMetric(t, reg, "mx_health_check_duration_seconds", map[string]string{"check": "db", "app": "app"})
+	if duration == nil || duration.GetHistogram().GetSampleCount() != 2 || duration.GetHistogram().GetSampleSum() != 0.75 {
+		t.Errorf("mx_health_check_duration_seconds = %v; want 2 samples summing to 0.75s", duration)
+	}
+}
+
+func TestLifecycleMetrics_ObserveHook(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := newLifecycleMetrics(reg, "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("newLifecycleMetrics: %v", err)
+	}
+
+	m.observeHook(hookRun{service: "db", phase: PhaseBeforeStart, hook: "migrate", duration: time.Second})
+	m.observeHook(hookRun{service: "db", phase: PhaseBeforeStart, hook: "migrate", duration: time.Second, err: errors.New("locked")})
+	// unnamed hooks are not recorded
+	m.observeHook(hookRun{service: "db", phase: PhaseBeforeStart, duration: time.Second})
+
+	labels := map[string]string{"service": "db", "phase": "before_start", "hook": "migrate"}
+	duration := findMetric(t, reg, "mx_hook_duration_seconds", labels)
+	if duration == nil || duration.GetHistogram().GetSampleCount() != 2 {
+		t.Errorf("mx_hook_duration_seconds = %v; want 2 samples", duration)
+	}
+	if got := testutil.ToFloat64(m.hookFailures.WithLabelValues("db", "before_start", "migrate")); got != 1 {
+		t.Errorf("mx_hook_failures_total = %v; want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.hookDuration); got != 1 {
+		t.Errorf("mx_hook_duration_seconds has %d series; want 1", got)
+	}
+}
+
+func TestLifecycleMetrics_ReusesRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first, err := newLifecycleMetrics(reg, "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("newLifecycleMetrics: %v", err)
+	}
+	second, err := newLifecycleMetrics(reg, "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("second newLifecycleMetrics: %v", err)
+	}
+	if first.state != second.state || first.checkDuration != second.checkDuration {
+		t.Fatal("metrics registered twice were not reused")
+	}
+}
+
+func TestLifecycleMetrics_Nil(t *testing.T) {
+	var m *lifecycleMetrics
+	m.init([]*Service{NewService(WithServiceName("svc"))})
+	m.launch(NewService(WithServiceName("svc")))
+	m.observe(LifecycleEvent{Service: "svc", To: ServiceStateRunning})
+	m.ObserveHealthCheck("svc", time.Second, nil)
+}
+
+// The launcher publishes its metrics to the configured registry when the ops
+// metrics server is enabled.
+func TestLauncher_Metrics(t *testing.T) {
+	started := make(chan struct{})
+	reg := prometheus.NewRegistry()
+
+	ln := New(
+		WithName("metrics-test"),
+		WithVersion("v1"),
+		WithSignal(false),
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithAfterStart(func() error { close(started); return nil }),
+		WithOpsConfig(ops.Config{
+			Enabled: true,
+			Network: "tcp",
+			Metrics: ops.MetricsConfig{Enabled: true, Path: "/metrics", Port: "0", Registerer: reg},
+		}),
+	)
+	ln.ServicesRunner().Register(NewService(
+		WithServiceName("worker"),
+		WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+		WithStop(func(context.Context) error { return nil }),
+	))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	select {
+	case <-started:
+	case err := <-errCh:
+		t.Fatalf("Run returned before startup completed: %v", err)
+	case <-time.After(10 * time.Second):
+		ln.Stop()
+		t.Fatal("services did not start in time")
+	}
+
+	labels := map[string]string{"app": "metrics-test", "version": "v1", "service": "worker", "state": "running"}
+	m := findMetric(t, reg, "mx_service_state", labels)
+	if m == nil || m.GetGauge().GetValue() != 1 {
+		t.Errorf("mx_service_state%v = %v; want 1", labels, m)
+	}
+
+	ln.Stop()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+	"github.com/tkcrm/mx/transport/http_transport"
+)
+
+var (
+	// ErrServiceNotFound is returned by an AdminController for a service that
+	// is not registered.
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrNotRunning is returned by an AdminController while the services
+	// cannot be controlled, e.g. during the shutdown.
+	ErrNotRunning = errors.New("launcher is not running")
+)
+
+// AdminConfig configures the admin API, which lets operators list, restart
+// and stop services and shut the application down without a redeploy. It is
+// served only with basic auth enabled.
+type AdminConfig struct {
+	Enabled   bool                           `default:"false" usage:"allows to enable the admin API" example:"false"`
+	Path      string                         `default:"/admin" validate:"required" usage:"allows to set custom admin API path prefix" example:"/admin"`
+	Port      string                         `default:"10000" validate:"required" usage:"allows to set custom admin API port" example:"10000"`
+	BasicAuth http_transport.BasicAuthConfig `yaml:"basic_auth"`
+
+	controller AdminController
+}
+
+// ServiceInfo describes a service in the admin API.
+type ServiceInfo struct {
+	Name     string
+	State    string
+	Restarts int
+	// Uptime is how long the service has been running, zero unless it is.
+	Uptime          time.Duration
+	StartupPriority int
+	// StopPriority is nil unless set on the service.
+	StopPriority *int
+	LastError    error
+}
+
+// AdminController executes the requests of the admin API. The launcher
+// implements it.
+type AdminController interface {
+	// ServiceInfos returns the registered services.
+	ServiceInfos() []ServiceInfo
+	// RestartService stops the named service and starts it again.
+	RestartService(ctx context.Context, name string) error
+	// StopService stops the named service without deregistering it. It
+	// returns the ctx error once ctx is done.
+	StopService(ctx context.Context, name string) error
+	// Shutdown starts the graceful shutdown of the application.
+	Shutdown()
+}
+
+// SetController sets the controller executing the admin API requests. The
+// admin API is not served without one.
+func (c *AdminConfig) SetController(ctl AdminController) {
+	c.controller = ctl
+}
+
+type adminOpsService struct {
+	log    logger.ExtendedLogger
+	config AdminConfig
+}
+
+func newAdminOpsService(log logger.ExtendedLogger, cfg AdminConfig) *adminOpsService {
+	if cfg.Path == "" {
+		cfg.Path = "/admin"
+	}
+	cfg.Path = strings.TrimSuffix(cfg.Path, "/")
+	return &adminOpsService{log: log, config: cfg}
+}
+
+func (s adminOpsService) Name() string { return "admin" }
+
+func (s adminOpsService) getEnabled() bool { return s.config.Enabled }
+
+func (s adminOpsService) getPort() string { return s.config.Port }
+
+func (s adminOpsService) getHTTPOptions() []http_transport.Option {
+	res := make([]http_transport.Option, 0)
+	return res
+}
+
+func (s *adminOpsService) initService(mux *http.ServeMux) {
+	if s.config.controller == nil {
+		s.log.Errorf("admin API is enabled without a controller, it is not served")
+		return
+	}
+	if !s.config.BasicAuth.Enabled {
+		s.log.Errorf("admin API is enabled without basic auth, it is not served")
+		return
+	}
+
+	handle := func(pattern string, fn http.HandlerFunc) {
+		mux.Handle(pattern, http_transport.BasicAuthHandler(fn, s.config.BasicAuth))
+	}
+
+	handle("GET "+s.config.Path+"/services", s.serveServices)
+	handle("POST "+s.config.Path+"/services/{name}/restart", s.serveRestart)
+	handle("POST "+s.config.Path+"/services/{name}/stop", s.serveStop)
+	handle("POST "+s.config.Path+"/shutdown", s.serveShutdown)
+}
+
+type adminServiceEntry struct {
+	Name            string `json:"name"`
+	State           string `json:"state"`
+	Restarts        int    `json:"restarts"`
+	Uptime          string `json:"uptime,omitempty"`
+	StartupPriority int    `json:"startup_priority"`
+	StopPriority    *int   `json:"stop_priority,omitempty"`
+	LastError       string `json:"last_error,omitempty"`
+}
+
+// serveServices lists the services with their state, restarts, uptime,
+// priorities and last error.
+func (s *adminOpsService) serveServices(w http.ResponseWriter, _ *http.Request) {
+	infos := s.config.controller.ServiceInfos()
+	entries := make([]adminServiceEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = adminServiceEntry{
+			Name:            info.Name,
+			State:           info.State,
+			Restarts:        info.Restarts,
+			StartupPriority: info.StartupPriority,
+			StopPriority:    info.StopPriority,
+		}
+		if info.Uptime > 0 {
+			entries[i].Uptime = info.Uptime.Round(time.Second).String()
+		}
+		if info.LastError != nil {
+			entries[i].LastError = info.LastError.Error()
+		}
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"services": entries})
+}
+
+// serveRestart restarts a service and returns once it is ready again.
+func (s *adminOpsService) serveRestart(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.log.Infof("admin API: restart of service [%s] requested", name)
+	s.writeResult(w, http.StatusOK, s.config.controller.RestartService(r.Context(), name))
+}
+
+// serveStop stops a service.
+func (s *adminOpsService) serveStop(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.log.Infof("admin API: stop of service [%s] requested", name)
+	s.writeResult(w, http.StatusOK, s.config.controller.StopService(r.Context(), name))
+}
+
+// serveShutdown starts the graceful shutdown and returns at once.
+func (s *adminOpsService) serveShutdown(w http.ResponseWriter, _ *http.Request) {
+	s.log.Infof("admin API: shutdown requested")
+	s.writeResult(w, http.StatusAccepted, nil)
+	s.config.controller.Shutdown()
+}
+
+// writeResult writes the outcome of an action: ok with code, or the error
+// with a status derived from it.
+func (s *adminOpsService) writeResult(w http.ResponseWriter, code int, err error) {
+	if err == nil {
+		s.writeJSON(w, code, map[string]any{"status": "ok"})
+		return
+	}
+
+	code = http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrServiceNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, ErrNotRunning):
+		code = http.StatusConflict
+	}
+	s.writeJSON(w, code, map[string]any{"status": "error", "error": err.Error()})
+}
+
+func (s *adminOpsService) writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Errorf("admin: could not write response: %s", err)
+	}
+}
+
+var _ opsService = (*adminOpsService)(nil)
@@ -0,0 +1,212 @@
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tkcrm/mx/transport/http_transport"
+)
+
+// fakeAdminController records the requests of the admin API.
+type fakeAdminController struct {
+	mu        sync.Mutex
+	infos     []ServiceInfo
+	err       error
+	restarted []string
+	stopped   []string
+	shutdown  bool
+}
+
+func (c *fakeAdminController) ServiceInfos() []ServiceInfo { return c.infos }
+
+func (c *fakeAdminController) RestartService(_ context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.restarted = append(c.restarted, name)
+	return c.err
+}
+
+func (c *fakeAdminController) StopService(_ context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = append(c.stopped, name)
+	return c.err
+}
+
+func (c *fakeAdminController) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shutdown = true
+}
+
+var _ AdminController = (*fakeAdminController)(nil)
+
+// adminAuth is the basic auth of the admin API in tests.
+var adminAuth = http_transport.BasicAuthConfig{Enabled: true, Username: "ops", Password: "secret"}
+
+// newAdminMux returns a mux serving the admin API backed by ctl.
+func newAdminMux(ctl AdminController, auth http_transport.BasicAuthConfig) *http.ServeMux {
+	cfg := AdminConfig{Enabled: true, Path: "/admin/", BasicAuth: auth}
+	cfg.SetController(ctl)
+
+	mux := http.NewServeMux()
+	newAdminOpsService(quietLog(), cfg).initService(mux)
+	return mux
+}
+
+// serveAdmin serves an authenticated request.
+func serveAdmin(mux *http.ServeMux, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.SetBasicAuth(adminAuth.Username, adminAuth.Password)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdmin_Services(t *testing.T) {
+	stopPriority := 5
+	ctl := &fakeAdminController{infos: []ServiceInfo{
+		{
+			Name:            "api",
+			State:           "running",
+			Restarts:        2,
+			Uptime:          90 * time.Second,
+			StartupPriority: 1,
+			StopPriority:    &stopPriority,
+			LastError:       errors.New("crashed"),
+		},
+		{Name: "worker", State: "stopped"},
+	}}
+
+	rec := serveAdmin(newAdminMux(ctl, adminAuth), http.MethodGet, "/admin/services")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+
+	var body struct {
+		Services []adminServiceEntry `json:"services"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Services) != 2 {
+		t.Fatalf("services = %+v; want 2", body.Services)
+	}
+
+	api := body.Services[0]
+	if api.Name != "api" || api.State != "running" || api.Restarts != 2 || api.Uptime != "1m30s" ||
+		api.StartupPriority != 1 || api.StopPriority == nil || *api.StopPriority != 5 || api.LastError != "crashed" {
+		t.Errorf("api = %+v; want all fields reported", api)
+	}
+	if worker := body.Services[1]; worker.Uptime != "" || worker.StopPriority != nil || worker.LastError != "" {
+		t.Errorf("worker = %+v; want empty optional fields", worker)
+	}
+}
+
+func TestAdmin_Actions(t *testing.T) {
+	ctl := &fakeAdminController{}
+	mux := newAdminMux(ctl, adminAuth)
+
+	if rec := serveAdmin(mux, http.MethodPost, "/admin/services/api/restart"); rec.Code != http.StatusOK {
+		t.Errorf("restart status = %d; want 200", rec.Code)
+	}
+	if rec := serveAdmin(mux, http.MethodPost, "/admin/services/worker/stop"); rec.Code != http.StatusOK {
+		t.Errorf("stop status = %d; want 200", rec.Code)
+	}
+	if rec := serveAdmin(mux, http.MethodPost, "/admin/shutdown"); rec.Code != http.StatusAccepted {
+		t.Errorf("shutdown status = %d; want 202", rec.Code)
+	}
+
+	if len(ctl.restarted) != 1 || ctl.restarted[0] != "api" {
+		t.Errorf("restarted = %v; want [api]", ctl.restarted)
+	}
+	if len(ctl.stopped) != 1 || ctl.stopped[0] != "worker" {
+		t.Errorf("stopped = %v; want [worker]", ctl.stopped)
+	}
+	if !ctl.shutdown {
+		t.Error("shutdown was not requested")
+	}
+
+	// actions are POST only
+	if rec := serveAdmin(mux, http.MethodGet, "/admin/shutdown"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET shutdown status = %d; want 405", rec.Code)
+	}
+}
+
+func TestAdmin_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: [api]", ErrServiceNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: cannot restart service [api]", ErrNotRunning), http.StatusConflict},
+		{errors.New("startup timeout"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		mux := newAdminMux(&fakeAdminController{err: tt.err}, adminAuth)
+
+		rec := serveAdmin(mux, http.MethodPost, "/admin/services/api/restart")
+		if rec.Code != tt.want {
+			t.Errorf("error %q: status = %d; want %d", tt.err, rec.Code, tt.want)
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if body["error"] != tt.err.Error() {
+			t.Errorf("error = %q; want %q", body["error"], tt.err)
+		}
+	}
+}
+
+func TestAdmin_BasicAuth(t *testing.T) {
+	ctl := &fakeAdminController{}
+	mux := newAdminMux(ctl, adminAuth)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/shutdown", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without credentials = %d; want 401", rec.Code)
+	}
+	if ctl.shutdown {
+		t.Fatal("shutdown requested without credentials")
+	}
+
+	if rec := serveAdmin(mux, http.MethodPost, "/admin/shutdown"); rec.Code != http.StatusAccepted || !ctl.shutdown {
+		t.Errorf("status = %d, shutdown = %v; want 202 and the shutdown requested", rec.Code, ctl.shutdown)
+	}
+}
+
+// Without basic auth the admin API is not served at all.
+func TestAdmin_WithoutBasicAuth(t *testing.T) {
+	ctl := &fakeAdminController{}
+	mux := newAdminMux(ctl, http_transport.BasicAuthConfig{})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/shutdown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d; want 404 without basic auth", rec.Code)
+	}
+	if ctl.shutdown {
+		t.Fatal("shutdown requested without basic auth")
+	}
+}
+
+func TestOpsNew_AdminWithoutController(t *testing.T) {
+	mux := http.NewServeMux()
+	newAdminOpsService(quietLog(), AdminConfig{Enabled: true, BasicAuth: adminAuth}).initService(mux)
+
+	if rec := serveAdmin(mux, http.MethodGet, "/admin/services"); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d; want 404 without a controller", rec.Code)
+	}
+}
@@ -20,6 +20,12 @@ type Config struct {
 
 	// Profiler
 	Profiler ProfilerConfig
+
+	// Admin API
+	Admin AdminConfig
+
+	// Log level endpoint
+	LogLevel LogLevelConfig `yaml:"log_level"`
 }
 
 func (c *Config) getHTTPOptionForPort(port string) http_transport.Option {
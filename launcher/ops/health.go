@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tkcrm/mx/logger"
@@ -24,8 +28,101 @@ const (
 var (
 	ErrHealthCheckError           = errors.New("health check error")
 	ErrHealthCheckServiceStarting = errors.New("service is starting")
+	ErrHealthCheckTimeout         = errors.New("health check timed out")
 )
 
+// healthCheckResult is the latest result of a single health checker.
+type healthCheckResult struct {
+	code HealthCheckCode
+	// lastErr is the last error the check failed with; kept after recovery.
+	lastErr              error
+	lastSuccess          time.Time
+	duration             time.Duration
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	details              map[string]any
+
+	// nonCritical checks only degrade readiness when failing
+	nonCritical bool
+	tags        []string
+}
+
+// newHealthCheckResult returns the initial result of checker.
+func newHealthCheckResult(checker mxtypes.HealthChecker) healthCheckResult {
+	res := healthCheckResult{code: HealthCheckCodeServiceStarting}
+	if c, ok := checker.(mxtypes.HealthCheckClassifier); ok {
+		res.nonCritical = !c.HealthCheckCritical()
+		res.tags = c.HealthCheckTags()
+	}
+	return res
+}
+
+// matches reports whether the result has any of tags; no tags match all.
+func (r healthCheckResult) matches(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range r.tags {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheckDetails is the verbose part of a health check result in the
+// probe JSON, shown only with the verbose query flag.
+type HealthCheckDetails struct {
+	LastError            string         `json:"last_error,omitempty"`
+	LastSuccess          *time.Time     `json:"last_success,omitempty"`
+	Duration             string         `json:"duration,omitempty"`
+	ConsecutiveFailures  int            `json:"consecutive_failures"`
+	ConsecutiveSuccesses int            `json:"consecutive_successes"`
+	Details              map[string]any `json:"details,omitempty"`
+	Critical             bool           `json:"critical"`
+	Tags                 []string       `json:"tags,omitempty"`
+}
+
+func (r healthCheckResult) verbose() *HealthCheckDetails {
+	res := &HealthCheckDetails{
+		ConsecutiveFailures:  r.consecutiveFailures,
+		ConsecutiveSuccesses: r.consecutiveSuccesses,
+		Details:              r.details,
+		Critical:             !r.nonCritical,
+		Tags:                 r.tags,
+	}
+	if r.lastErr != nil {
+		res.LastError = r.lastErr.Error()
+	}
+	if !r.lastSuccess.IsZero() {
+		res.LastSuccess = &r.lastSuccess
+	}
+	if r.duration > 0 {
+		res.Duration = r.duration.String()
+	}
+	return res
+}
+
+// requestTags returns the tags of the ?tag= filter; ?tag=db&tag=cache selects
+// the checks with any of them.
+func requestTags(r *http.Request) []string {
+	return r.URL.Query()["tag"]
+}
+
+// isVerbose reports whether the request asks for the verbose probe output
+// with ?verbose, ?verbose=1 or ?verbose=true.
+func isVerbose(r *http.Request) bool {
+	v, ok := r.URL.Query()["verbose"]
+	if !ok {
+		return false
+	}
+	if v[0] == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(v[0])
+	return err == nil && b
+}
+
 // health implements service lifecycle
 // and used as worker pool for HealthChecker.
 type healthCheckerOpsService struct {
@@ -33,6 +130,11 @@ type healthCheckerOpsService struct {
 	config HealthCheckerConfig
 
 	resp *sync.Map
+	// inflight holds, by checker name, the result channel of a Healthy call
+	// abandoned on timeout and still running.
+	inflight *sync.Map
+	// started latches the first passing startup probe.
+	started *atomic.Bool
 }
 
 type HealthCheckerConfig struct {
@@ -48,8 +150,72 @@ type HealthCheckerConfig struct {
 	// Empty string disables the endpoint.
 	ReadinessPath string `default:"/readyz" usage:"readiness probe path" example:"/readyz"`
 
+	// StartupPath is the HTTP path for the startup probe (/startupz).
+	// Empty string disables the endpoint.
+	StartupPath string `default:"/startupz" usage:"startup probe path" example:"/startupz"`
+
+	// CheckTimeout bounds every Healthy call. Zero means 5 seconds.
+	CheckTimeout time.Duration `default:"5s" usage:"timeout of a single health check" example:"5s"`
+
+	// FailureThreshold is the number of consecutive failures before a check is
+	// reported unhealthy, and SuccessThreshold the number of consecutive
+	// successes before it is reported healthy again. Zero means 1.
+	FailureThreshold int `default:"1" usage:"consecutive failures before a check is unhealthy" example:"3"`
+	SuccessThreshold int `default:"1" usage:"consecutive successes before a check is healthy again" example:"2"`
+
 	servicesList []mxtypes.HealthChecker
 	statesList   []mxtypes.StateProvider
+	registry     ServicesRegistry
+	observer     HealthCheckObserver
+	startup      StartupTracker
+	drain        DrainReporter
+	liveness     LivenessReporter
+}
+
+// HealthCheckObserver receives the outcome and duration of every health check,
+// e.g. to export them as metrics.
+type HealthCheckObserver interface {
+	ObserveHealthCheck(name string, duration time.Duration, err error)
+}
+
+// HealthStatusObserver can be implemented by a HealthCheckObserver to also
+// receive the status reported for a check after every poll, i.e. after the
+// FailureThreshold and SuccessThreshold damping: nil while the check is
+// reported healthy, its last error while it is reported unhealthy. Polls of
+// a service still starting are not reported.
+type HealthStatusObserver interface {
+	ObserveHealthStatus(name string, err error)
+}
+
+// StartupTracker reports the startup progress of the application to the
+// startup probe.
+type StartupTracker interface {
+	// StartupComplete reports whether every startup-priority group was
+	// launched and every service passed its readiness gate.
+	StartupComplete() bool
+	// TimeToReady returns how long the named service took from its launch
+	// to become ready, or false if it is not ready yet.
+	TimeToReady(name string) (time.Duration, bool)
+}
+
+// DrainReporter reports whether the application is draining before shutdown.
+type DrainReporter interface {
+	Draining() bool
+}
+
+// LivenessReporter reports an application-level reason for the liveness
+// probe to fail, such as an exhausted restart budget, or nil.
+type LivenessReporter interface {
+	LivenessFailure() error
+}
+
+// ServicesRegistry provides the health checker with the current set of
+// services, so the probes follow services added or removed at runtime.
+type ServicesRegistry interface {
+	HealthCheckers() []mxtypes.HealthChecker
+	StateProviders() []mxtypes.StateProvider
+	// Changed returns a channel that is closed on the next change of the set.
+	Changed() <-chan struct{}
 }
 
 func (s *HealthCheckerConfig) AddServicesList(list []mxtypes.HealthChecker) {
@@ -60,6 +226,78 @@ func (s *HealthCheckerConfig) AddStateList(list []mxtypes.StateProvider) {
 	s.statesList = list
 }
 
+// SetServicesRegistry sets a live source of services. It takes precedence
+// over the static lists set with AddServicesList and AddStateList.
+func (s *HealthCheckerConfig) SetServicesRegistry(r ServicesRegistry) {
+	s.registry = r
+}
+
+// SetObserver sets an observer notified of every health check result.
+func (s *HealthCheckerConfig) SetObserver(o HealthCheckObserver) {
+	s.observer = o
+}
+
+// SetStartupTracker sets the source of the startup progress. Without it the
+// startup probe passes once no service is idle or starting.
+func (s *HealthCheckerConfig) SetStartupTracker(t StartupTracker) {
+	s.startup = t
+}
+
+// SetDrainReporter sets the source of the drain state. While it reports
+// draining, the readiness probe fails with status "draining".
+func (s *HealthCheckerConfig) SetDrainReporter(d DrainReporter) {
+	s.drain = d
+}
+
+// SetLivenessReporter sets a source of application-level liveness failures.
+// While it reports one, the liveness probe fails with its reason.
+func (s *HealthCheckerConfig) SetLivenessReporter(r LivenessReporter) {
+	s.liveness = r
+}
+
+// policy returns the health check policy of checker: its own, if it
+// implements mxtypes.HealthCheckPolicyProvider, completed with the defaults.
+func (s *HealthCheckerConfig) policy(checker mxtypes.HealthChecker) mxtypes.HealthCheckPolicy {
+	var p mxtypes.HealthCheckPolicy
+	if pp, ok := checker.(mxtypes.HealthCheckPolicyProvider); ok {
+		p = pp.HealthCheckPolicy()
+	}
+
+	if p.Timeout <= 0 {
+		p.Timeout = s.CheckTimeout
+	}
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = max(s.FailureThreshold, 1)
+	}
+	if p.SuccessThreshold <= 0 {
+		p.SuccessThreshold = max(s.SuccessThreshold, 1)
+	}
+	return p
+}
+
+func (s *HealthCheckerConfig) checkers() []mxtypes.HealthChecker {
+	if s.registry != nil {
+		return s.registry.HealthCheckers()
+	}
+	return s.servicesList
+}
+
+func (s *HealthCheckerConfig) states() []mxtypes.StateProvider {
+	if s.registry != nil {
+		return s.registry.StateProviders()
+	}
+	return s.statesList
+}
+
+// changes returns a channel closed on the next change of the services set,
+// or nil (blocking forever) for static lists.
+func (s *HealthCheckerConfig) changes() <-chan struct{} {
+	if s.registry != nil {
+		return s.registry.Changed()
+	}
+	return nil
+}
+
 func newHealthCheckerOpsService(
 	log logger.ExtendedLogger,
 	config HealthCheckerConfig,
@@ -73,32 +311,64 @@ func newHealthCheckerOpsService(
 	if config.ReadinessPath == "" {
 		config.ReadinessPath = "/readyz"
 	}
+	if config.StartupPath == "" {
+		config.StartupPath = "/startupz"
+	}
+	if config.CheckTimeout <= 0 {
+		config.CheckTimeout = 5 * time.Second
+	}
 	return &healthCheckerOpsService{
-		log:    log,
-		config: config,
-		resp:   new(sync.Map),
+		log:      log,
+		config:   config,
+		resp:     new(sync.Map),
+		inflight: new(sync.Map),
+		started:  new(atomic.Bool),
 	}
 }
 
 // Name returns name of http server.
 func (s healthCheckerOpsService) Name() string { return "ops-health-checker" }
 
+type healthyVerboseEntry struct {
+	Code HealthCheckCode `json:"code"`
+	*HealthCheckDetails
+}
+
 // ServeHTTP implementation of http.Handler for OPS worker.
-func (s *healthCheckerOpsService) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+// It reports the code of every health check, and with the verbose query
+// flag also the details of the last result. Failing non-critical checks do
+// not fail the response; ?tag= limits it to the checks with those tags.
+func (s *healthCheckerOpsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	verbose := isVerbose(r)
+	tags := requestTags(r)
+
 	var existsErr, existsProcessing bool
 	out := make(map[string]any)
 	s.resp.Range(func(key, val any) bool {
-		if name, ok := key.(string); ok {
-			out[name] = val
+		name, ok := key.(string)
+		if !ok {
+			return true
+		}
+		res, ok := val.(healthCheckResult)
+		if !ok || !res.matches(tags) {
+			return true
 		}
 
-		if code, ok := val.(HealthCheckCode); ok {
-			switch code {
-			case HealthCheckCodeError:
-				existsErr = true
-			case HealthCheckCodeServiceStarting:
-				existsProcessing = true
-			}
+		if verbose {
+			out[name] = healthyVerboseEntry{Code: res.code, HealthCheckDetails: res.verbose()}
+		} else {
+			out[name] = res.code
+		}
+
+		if res.nonCritical {
+			return true
+		}
+
+		switch res.code {
+		case HealthCheckCodeError:
+			existsErr = true
+		case HealthCheckCodeServiceStarting:
+			existsProcessing = true
 		}
 
 		return true
@@ -125,49 +395,89 @@ func (s *healthCheckerOpsService) ServeHTTP(w http.ResponseWriter, _ *http.Reque
 }
 
 // Start implements service lifecycle for OPS health checker worker.
+// It runs a poller per health checker and, when a services registry is set,
+// starts and stops pollers as services are added or removed.
 func (s *healthCheckerOpsService) Start(ctx context.Context) error {
 	wg := new(sync.WaitGroup)
+	pollers := make(map[string]context.CancelFunc)
+
+	for {
+		// subscribe before reading the list so no change is missed
+		changed := s.config.changes()
+		s.syncPollers(ctx, wg, pollers)
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+		case <-changed:
+		}
+	}
+}
 
-	wg.Add(len(s.config.servicesList))
-	for i := range len(s.config.servicesList) {
-		if s.config.servicesList[i] == nil {
-			wg.Done()
+// syncPollers starts a poller for every new health checker and stops the
+// pollers of checkers that are gone.
+func (s *healthCheckerOpsService) syncPollers(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	pollers map[string]context.CancelFunc,
+) {
+	current := make(map[string]struct{})
+	for _, checker := range s.config.checkers() {
+		if checker == nil {
 			continue
 		}
 
-		s.resp.Store(s.config.servicesList[i].Name(), HealthCheckCodeServiceStarting)
+		name := checker.Name()
+		current[name] = struct{}{}
+		if _, ok := pollers[name]; ok {
+			continue
+		}
 
-		go func(checker mxtypes.HealthChecker) {
-			defer wg.Done()
+		s.resp.Store(name, newHealthCheckResult(checker))
 
-			delay := checker.Interval()
+		pollCtx, cancel := context.WithCancel(ctx)
+		pollers[name] = cancel
+		wg.Go(func() { s.runPoller(ctx, pollCtx, checker) })
+	}
 
-			// Poll immediately, then every interval, so a healthy service is
-			// reported ready right away instead of after a full interval of
-			// "starting".
-			timer := time.NewTimer(0)
-			defer timer.Stop()
+	for name, cancel := range pollers {
+		if _, ok := current[name]; !ok {
+			cancel()
+			delete(pollers, name)
+		}
+	}
+}
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-timer.C:
-					s.poll(ctx, checker)
-					timer.Reset(delay)
-				}
+// runPoller polls checker until pollCtx is done. When the checker was removed
+// (pollCtx cancelled while ctx is still alive) its result is dropped.
+func (s *healthCheckerOpsService) runPoller(ctx, pollCtx context.Context, checker mxtypes.HealthChecker) {
+	delay := checker.Interval()
+
+	// Poll immediately, then every interval, so a healthy service is
+	// reported ready right away instead of after a full interval of
+	// "starting".
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-pollCtx.Done():
+			if ctx.Err() == nil {
+				s.resp.Delete(checker.Name())
 			}
-		}(s.config.servicesList[i])
+			return
+		case <-timer.C:
+			s.poll(pollCtx, checker)
+			timer.Reset(delay)
+		}
 	}
-
-	<-ctx.Done()
-
-	wg.Wait()
-
-	return nil
 }
 
-// poll runs a single health check and records the result, logging only on
+// poll runs a single health check, bounded by the policy timeout, and records
+// the result, together with its duration, error, streaks and the checker
+// details. The reported code changes only once a failure or success streak
+// reaches the policy threshold, which damps flapping checks. It logs only on
 // meaningful transitions:
 //   - a service still starting up (reporting ErrHealthCheckServiceStarting) is
 //     recorded as "starting" and stays silent — that is expected, not an error;
@@ -177,29 +487,107 @@ func (s *healthCheckerOpsService) Start(ctx context.Context) error {
 func (s *healthCheckerOpsService) poll(ctx context.Context, checker mxtypes.HealthChecker) {
 	name := checker.Name()
 
-	prev := HealthCheckCodeServiceStarting
+	prev := healthCheckResult{code: HealthCheckCodeServiceStarting}
 	if v, ok := s.resp.Load(name); ok {
-		if code, ok := v.(HealthCheckCode); ok {
-			prev = code
+		if res, ok := v.(healthCheckResult); ok {
+			prev = res
 		}
 	}
 
-	err := checker.Healthy(ctx)
+	policy := s.config.policy(checker)
+
+	started := time.Now()
+	err := s.check(ctx, checker, policy.Timeout)
+	duration := time.Since(started)
+	if ctx.Err() != nil {
+		// the checker was removed or the service is stopping
+		return
+	}
+	if s.config.observer != nil {
+		s.config.observer.ObserveHealthCheck(name, duration, err)
+	}
+
+	res := newHealthCheckResult(checker)
+	res.lastErr = prev.lastErr
+	res.lastSuccess = prev.lastSuccess
+	res.duration = duration
+	if d, ok := checker.(mxtypes.HealthDetailer); ok {
+		res.details = d.HealthDetails()
+	}
+
 	switch {
 	case err == nil:
-		s.resp.Store(name, HealthCheckCodeOk)
-		if prev == HealthCheckCodeError {
-			s.log.Infof("health check service %s recovered", name)
+		res.lastSuccess = started.Add(duration)
+		res.consecutiveSuccesses = prev.consecutiveSuccesses + 1
+		res.code = HealthCheckCodeOk
+		if prev.code == HealthCheckCodeError {
+			if res.consecutiveSuccesses < policy.SuccessThreshold {
+				res.code = HealthCheckCodeError
+			} else {
+				s.log.Infof("health check service %s recovered", name)
+			}
 		}
 	case errors.Is(err, ErrHealthCheckServiceStarting):
 		// Expected while the service is still starting up — not a failure.
-		s.resp.Store(name, HealthCheckCodeServiceStarting)
+		res.code = HealthCheckCodeServiceStarting
 	default:
-		s.resp.Store(name, HealthCheckCodeError)
-		if prev != HealthCheckCodeError {
-			s.log.Warnf("health check service %s failed: %s", name, err)
+		res.lastErr = err
+		res.consecutiveFailures = prev.consecutiveFailures + 1
+		res.code = HealthCheckCodeError
+		if prev.code != HealthCheckCodeError {
+			if res.consecutiveFailures < policy.FailureThreshold {
+				res.code = prev.code
+			} else {
+				s.log.Warnf("health check service %s failed: %s", name, err)
+			}
 		}
 	}
+
+	s.resp.Store(name, res)
+
+	if o, ok := s.config.observer.(HealthStatusObserver); ok && res.code != HealthCheckCodeServiceStarting {
+		var status error
+		if res.code == HealthCheckCodeError {
+			status = res.lastErr
+		}
+		o.ObserveHealthStatus(name, status)
+	}
+}
+
+// check calls checker.Healthy. With a timeout the call gets a context with
+// that deadline and is abandoned once it expires, so a checker that ignores
+// its context cannot stall the poller. While an abandoned call is still
+// running no new one is started, so a hanging checker holds one goroutine
+// rather than one per poll.
+func (s *healthCheckerOpsService) check(ctx context.Context, checker mxtypes.HealthChecker, timeout time.Duration) error {
+	if timeout <= 0 {
+		return checker.Healthy(ctx)
+	}
+
+	name := checker.Name()
+	if v, ok := s.inflight.Load(name); ok {
+		select {
+		case <-v.(chan error):
+			// finished since; its result is stale
+			s.inflight.Delete(name)
+		default:
+			return fmt.Errorf("%w: the previous check is still running", ErrHealthCheckTimeout)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- checker.Healthy(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		s.inflight.Store(name, done)
+		return fmt.Errorf("%w after %s", ErrHealthCheckTimeout, timeout)
+	}
 }
 
 func (s *healthCheckerOpsService) Stop(_ context.Context) error { return nil }
@@ -221,16 +609,21 @@ func (s *healthCheckerOpsService) initService(mux *http.ServeMux) {
 	if s.config.ReadinessPath != "" {
 		mux.HandleFunc(s.config.ReadinessPath, s.serveReadiness)
 	}
+	if s.config.StartupPath != "" {
+		mux.HandleFunc(s.config.StartupPath, s.serveStartup)
+	}
 }
 
 // serveLiveness handles the /livez liveness probe.
-// Returns 200 if no service is in Failed state, 503 otherwise.
+// Returns 200 if no service is in Failed state and the liveness reporter, if
+// any, reports no failure; 503 otherwise.
 // Liveness does not require HealthChecker — it reads ServiceState directly.
 func (s *healthCheckerOpsService) serveLiveness(w http.ResponseWriter, _ *http.Request) {
-	services := make(map[string]string, len(s.config.statesList))
+	states := s.config.states()
+	services := make(map[string]string, len(states))
 	hasFailed := false
 
-	for _, sp := range s.config.statesList {
+	for _, sp := range states {
 		state := sp.State()
 		services[sp.Name()] = state.String()
 		if state == mxtypes.ServiceStateFailed {
@@ -238,20 +631,26 @@ func (s *healthCheckerOpsService) serveLiveness(w http.ResponseWriter, _ *http.R
 		}
 	}
 
+	res := map[string]any{"services": services}
+	if s.config.liveness != nil {
+		if err := s.config.liveness.LivenessFailure(); err != nil {
+			hasFailed = true
+			res["reason"] = err.Error()
+		}
+	}
+
 	status := "ok"
 	resCode := http.StatusOK
 	if hasFailed {
 		status = "failed"
 		resCode = http.StatusServiceUnavailable
 	}
+	res["status"] = status
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(resCode)
 
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"status":   status,
-		"services": services,
-	}); err != nil {
+	if err := json.NewEncoder(w).Encode(res); err != nil {
 		s.log.Errorf("livez: could not write response: %s", err)
 	}
 }
@@ -259,19 +658,46 @@ func (s *healthCheckerOpsService) serveLiveness(w http.ResponseWriter, _ *http.R
 type readinessServiceEntry struct {
 	State  string `json:"state"`
 	Health string `json:"health"`
+	*HealthCheckDetails
 }
 
 // serveReadiness handles the /readyz readiness probe.
 // Combines ServiceState with HealthChecker poll results.
-// Returns 200 only when all services are Running and all health checks pass.
+// Returns 200 only when all services are Running and all critical health
+// checks pass; a failing non-critical check reports "degraded" with 200.
 // Returns 424 if any service is Starting/Idle or a health check is still starting.
-// Returns 503 if any service is Failed or a health check returned an error.
-func (s *healthCheckerOpsService) serveReadiness(w http.ResponseWriter, _ *http.Request) {
-	entries := make(map[string]*readinessServiceEntry, len(s.config.statesList))
-	var existsErr, existsStarting bool
+// Returns 503 if any service is Failed or Stopped or a critical health check
+// returned an error, and with status "draining" while the application drains before shutdown.
+// With ?tag= only the checks with those tags, and their services, are
+// evaluated. With the verbose query flag, entries include the health check details.
+func (s *healthCheckerOpsService) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	verbose := isVerbose(r)
+	tags := requestTags(r)
+
+	results := make(map[string]healthCheckResult)
+	s.resp.Range(func(key, val any) bool {
+		name, ok := key.(string)
+		if !ok {
+			return true
+		}
+		res, ok := val.(healthCheckResult)
+		if !ok || !res.matches(tags) {
+			return true
+		}
+		results[name] = res
+		return true
+	})
+
+	states := s.config.states()
+	entries := make(map[string]*readinessServiceEntry, len(states))
+	var existsErr, existsStarting, existsDegraded bool
 
 	// populate state for every registered service
-	for _, sp := range s.config.statesList {
+	for _, sp := range states {
+		if _, ok := results[sp.Name()]; len(tags) > 0 && !ok {
+			continue
+		}
+
 		state := sp.State()
 		entry := &readinessServiceEntry{
 			State:  state.String(),
@@ -280,7 +706,8 @@ func (s *healthCheckerOpsService) serveReadiness(w http.ResponseWriter, _ *http.
 		entries[sp.Name()] = entry
 
 		switch state {
-		case mxtypes.ServiceStateFailed:
+		case mxtypes.ServiceStateFailed, mxtypes.ServiceStateStopped:
+			// a stopped service, e.g. through the admin API, serves nothing
 			existsErr = true
 		case mxtypes.ServiceStateStarting, mxtypes.ServiceStateIdle:
 			existsStarting = true
@@ -288,37 +715,39 @@ func (s *healthCheckerOpsService) serveReadiness(w http.ResponseWriter, _ *http.
 	}
 
 	// overlay HealthChecker poll results
-	s.resp.Range(func(key, val any) bool {
-		name, ok := key.(string)
-		if !ok {
-			return true
-		}
-		code, ok := val.(HealthCheckCode)
-		if !ok {
-			return true
-		}
-
+	for name, res := range results {
 		entry, exists := entries[name]
 		if !exists {
 			entry = &readinessServiceEntry{State: "unknown"}
 			entries[name] = entry
 		}
+		if verbose {
+			entry.HealthCheckDetails = res.verbose()
+		}
 
-		switch code {
+		switch res.code {
 		case HealthCheckCodeOk:
 			entry.Health = "ok"
 		case HealthCheckCodeError:
 			entry.Health = "error"
-			existsErr = true
+			if res.nonCritical {
+				existsDegraded = true
+			} else {
+				existsErr = true
+			}
 		case HealthCheckCodeServiceStarting:
 			entry.Health = "starting"
-			existsStarting = true
+			if !res.nonCritical {
+				existsStarting = true
+			}
 		}
-		return true
-	})
+	}
 
 	status := "ok"
 	resCode := http.StatusOK
+	if existsDegraded {
+		status = "degraded"
+	}
 	if existsStarting {
 		status = "starting"
 		resCode = http.StatusFailedDependency
@@ -327,6 +756,10 @@ func (s *healthCheckerOpsService) serveReadiness(w http.ResponseWriter, _ *http.
 		status = "unavailable"
 		resCode = http.StatusServiceUnavailable
 	}
+	if s.config.drain != nil && s.config.drain.Draining() {
+		status = "draining"
+		resCode = http.StatusServiceUnavailable
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(resCode)
@@ -339,4 +772,75 @@ func (s *healthCheckerOpsService) serveReadiness(w http.ResponseWriter, _ *http.
 	}
 }
 
+type startupServiceEntry struct {
+	State       string `json:"state"`
+	TimeToReady string `json:"time_to_ready,omitempty"`
+}
+
+// serveStartup handles the /startupz startup probe.
+// Returns 200 once the startup is complete: with a StartupTracker, when every
+// startup-priority group was launched and every service passed its readiness
+// gate; without one, when no service is idle or starting. Once passed, the
+// probe keeps passing. Returns 424 while the startup is in progress and 503
+// if a service failed before it. Entries include the time to ready of the
+// services that became ready.
+func (s *healthCheckerOpsService) serveStartup(w http.ResponseWriter, _ *http.Request) {
+	states := s.config.states()
+	entries := make(map[string]*startupServiceEntry, len(states))
+	var existsFailed, existsStarting bool
+
+	for _, sp := range states {
+		state := sp.State()
+		entry := &startupServiceEntry{State: state.String()}
+		entries[sp.Name()] = entry
+
+		if s.config.startup != nil {
+			if d, ok := s.config.startup.TimeToReady(sp.Name()); ok {
+				entry.TimeToReady = d.String()
+			}
+		}
+
+		switch state {
+		case mxtypes.ServiceStateFailed:
+			existsFailed = true
+		case mxtypes.ServiceStateStarting, mxtypes.ServiceStateIdle:
+			existsStarting = true
+		}
+	}
+
+	complete := s.started.Load()
+	if !complete {
+		if s.config.startup != nil {
+			complete = s.config.startup.StartupComplete()
+		} else {
+			complete = !existsStarting && !existsFailed
+		}
+		if complete {
+			s.started.Store(true)
+		}
+	}
+
+	status := "started"
+	resCode := http.StatusOK
+	switch {
+	case complete:
+	case existsFailed:
+		status = "failed"
+		resCode = http.StatusServiceUnavailable
+	default:
+		status = "starting"
+		resCode = http.StatusFailedDependency
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resCode)
+
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"status":   status,
+		"services": entries,
+	}); err != nil {
+		s.log.Errorf("startupz: could not write response: %s", err)
+	}
+}
+
 var _ opsService = (*healthCheckerOpsService)(nil)
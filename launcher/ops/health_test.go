@@ -3,8 +3,11 @@ package ops
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -26,6 +29,9 @@ func TestNewHealthCheckerOpsService_Defaults(t *testing.T) {
 	if svc.config.ReadinessPath != "/readyz" {
 		t.Errorf("ReadinessPath = %q; want /readyz", svc.config.ReadinessPath)
 	}
+	if svc.config.StartupPath != "/startupz" {
+		t.Errorf("StartupPath = %q; want /startupz", svc.config.StartupPath)
+	}
 
 	// Explicit paths must be preserved.
 	custom := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{
@@ -76,7 +82,7 @@ func TestHealthChecker_ServeHTTP_StatusCodes(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{})
 			for k, v := range tt.codes {
-				svc.resp.Store(k, v)
+				svc.resp.Store(k, healthCheckResult{code: v})
 			}
 
 			rec := httptest.NewRecorder()
@@ -200,6 +206,12 @@ func TestHealthChecker_ServeReadiness(t *testing.T) {
 			wantCode:   http.StatusOK,
 			wantStat:   "ok",
 		},
+		{
+			name:     "stopped service",
+			states:   map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateRunning, "b": mxtypes.ServiceStateStopped},
+			wantCode: http.StatusServiceUnavailable,
+			wantStat: "unavailable",
+		},
 		{
 			name:       "health for unknown service",
 			states:     map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateRunning},
@@ -220,7 +232,7 @@ func TestHealthChecker_ServeReadiness(t *testing.T) {
 
 			svc := newHealthCheckerOpsService(quietLog(), cfg)
 			for name, code := range tt.healthResp {
-				svc.resp.Store(name, code)
+				svc.resp.Store(name, healthCheckResult{code: code})
 			}
 
 			rec := httptest.NewRecorder()
@@ -243,6 +255,217 @@ func TestHealthChecker_ServeReadiness(t *testing.T) {
 	}
 }
 
+// drainFlag is a DrainReporter backed by a bool.
+type drainFlag bool
+
+func (d *drainFlag) Draining() bool { return bool(*d) }
+
+func TestHealthChecker_ServeReadiness_Draining(t *testing.T) {
+	var draining drainFlag
+	cfg := HealthCheckerConfig{}
+	cfg.AddStateList([]mxtypes.StateProvider{fakeStateProvider{name: "a", state: mxtypes.ServiceStateRunning}})
+	cfg.SetDrainReporter(&draining)
+	svc := newHealthCheckerOpsService(quietLog(), cfg)
+
+	rec := httptest.NewRecorder()
+	svc.serveReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200 before draining", rec.Code)
+	}
+
+	draining = true
+	rec = httptest.NewRecorder()
+	svc.serveReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d; want 503 while draining", rec.Code)
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Status != "draining" {
+		t.Errorf("status = %q; want draining", body.Status)
+	}
+
+	// liveness is not affected by the drain
+	rec = httptest.NewRecorder()
+	svc.serveLiveness(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/livez status = %d; want 200 while draining", rec.Code)
+	}
+}
+
+// livenessErr is a LivenessReporter backed by an error.
+type livenessErr struct{ err error }
+
+func (l *livenessErr) LivenessFailure() error { return l.err }
+
+func TestHealthChecker_ServeLiveness_Reporter(t *testing.T) {
+	var reporter livenessErr
+	cfg := HealthCheckerConfig{}
+	cfg.AddStateList([]mxtypes.StateProvider{fakeStateProvider{name: "a", state: mxtypes.ServiceStateRunning}})
+	cfg.SetLivenessReporter(&reporter)
+	svc := newHealthCheckerOpsService(quietLog(), cfg)
+
+	rec := httptest.NewRecorder()
+	svc.serveLiveness(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200 without a liveness failure", rec.Code)
+	}
+
+	reporter.err = errors.New("restart budget exceeded")
+	rec = httptest.NewRecorder()
+	svc.serveLiveness(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d; want 503 with a liveness failure", rec.Code)
+	}
+	var body struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Status != "failed" || body.Reason != "restart budget exceeded" {
+		t.Errorf("body = %+v; want status failed with the reason", body)
+	}
+}
+
+// fakeStartupTracker is a StartupTracker with fixed answers.
+type fakeStartupTracker struct {
+	complete    bool
+	timeToReady map[string]time.Duration
+}
+
+func (f *fakeStartupTracker) StartupComplete() bool { return f.complete }
+
+func (f *fakeStartupTracker) TimeToReady(name string) (time.Duration, bool) {
+	d, ok := f.timeToReady[name]
+	return d, ok
+}
+
+func TestHealthChecker_ServeStartup(t *testing.T) {
+	tests := []struct {
+		name     string
+		states   map[string]mxtypes.ServiceState
+		tracker  *fakeStartupTracker
+		wantCode int
+		wantStat string
+	}{
+		{
+			name:     "all running without tracker",
+			states:   map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateRunning, "b": mxtypes.ServiceStateRunning},
+			wantCode: http.StatusOK,
+			wantStat: "started",
+		},
+		{
+			name:     "starting without tracker",
+			states:   map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateRunning, "b": mxtypes.ServiceStateStarting},
+			wantCode: http.StatusFailedDependency,
+			wantStat: "starting",
+		},
+		{
+			name:     "failed without tracker",
+			states:   map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateFailed, "b": mxtypes.ServiceStateStarting},
+			wantCode: http.StatusServiceUnavailable,
+			wantStat: "failed",
+		},
+		{
+			name:     "running but tracker incomplete",
+			states:   map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateRunning},
+			tracker:  &fakeStartupTracker{},
+			wantCode: http.StatusFailedDependency,
+			wantStat: "starting",
+		},
+		{
+			name:     "tracker complete",
+			states:   map[string]mxtypes.ServiceState{"a": mxtypes.ServiceStateRunning},
+			tracker:  &fakeStartupTracker{complete: true},
+			wantCode: http.StatusOK,
+			wantStat: "started",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := HealthCheckerConfig{}
+			providers := make([]mxtypes.StateProvider, 0, len(tt.states))
+			for name, st := range tt.states {
+				providers = append(providers, fakeStateProvider{name: name, state: st})
+			}
+			cfg.AddStateList(providers)
+			if tt.tracker != nil {
+				cfg.SetStartupTracker(tt.tracker)
+			}
+
+			svc := newHealthCheckerOpsService(quietLog(), cfg)
+
+			rec := httptest.NewRecorder()
+			svc.serveStartup(rec, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d; want %d", rec.Code, tt.wantCode)
+			}
+			var body struct {
+				Status   string                         `json:"status"`
+				Services map[string]startupServiceEntry `json:"services"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if body.Status != tt.wantStat {
+				t.Errorf("status = %q; want %q", body.Status, tt.wantStat)
+			}
+			if len(body.Services) != len(tt.states) {
+				t.Errorf("services len = %d; want %d", len(body.Services), len(tt.states))
+			}
+		})
+	}
+}
+
+func TestHealthChecker_ServeStartup_Latches(t *testing.T) {
+	tracker := &fakeStartupTracker{
+		complete:    true,
+		timeToReady: map[string]time.Duration{"db": 1500 * time.Millisecond},
+	}
+	cfg := HealthCheckerConfig{}
+	cfg.AddStateList([]mxtypes.StateProvider{
+		fakeStateProvider{name: "db", state: mxtypes.ServiceStateRunning},
+		fakeStateProvider{name: "worker", state: mxtypes.ServiceStateRunning},
+	})
+	cfg.SetStartupTracker(tracker)
+	svc := newHealthCheckerOpsService(quietLog(), cfg)
+
+	rec := httptest.NewRecorder()
+	svc.serveStartup(rec, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+
+	var body struct {
+		Services map[string]startupServiceEntry `json:"services"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got := body.Services["db"].TimeToReady; got != "1.5s" {
+		t.Errorf("db time_to_ready = %q; want 1.5s", got)
+	}
+	if got := body.Services["worker"].TimeToReady; got != "" {
+		t.Errorf("worker time_to_ready = %q; want none", got)
+	}
+
+	// a passed startup probe keeps passing, e.g. while a service restarts
+	tracker.complete = false
+	rec = httptest.NewRecorder()
+	svc.serveStartup(rec, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status after startup = %d; want 200", rec.Code)
+	}
+}
+
 func TestHealthChecker_InitService_Routes(t *testing.T) {
 	cfg := HealthCheckerConfig{Path: "/healthy"}
 	cfg.AddStateList([]mxtypes.StateProvider{fakeStateProvider{name: "a", state: mxtypes.ServiceStateRunning}})
@@ -254,7 +477,7 @@ func TestHealthChecker_InitService_Routes(t *testing.T) {
 	ts := httptest.NewServer(mux)
 	t.Cleanup(ts.Close)
 
-	for _, path := range []string{"/healthy", "/livez", "/readyz"} {
+	for _, path := range []string{"/healthy", "/livez", "/readyz", "/startupz"} {
 		resp, err := ts.Client().Get(ts.URL + path)
 		if err != nil {
 			t.Fatalf("GET %s: %v", path, err)
@@ -405,6 +628,116 @@ func TestHealthChecker_HealthyService_ReadyImmediately(t *testing.T) {
 	})
 }
 
+// recordingObserver collects the health check results it is notified of.
+type recordingObserver struct {
+	mu      sync.Mutex
+	results map[string][]error
+}
+
+func (o *recordingObserver) ObserveHealthCheck(name string, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.results[name] = append(o.results[name], err)
+}
+
+// Every poll is reported to the observer, together with its error.
+func TestHealthChecker_Start_NotifiesObserver(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var calls atomic.Int32
+		db := &fakeHealthChecker{
+			name:     "db",
+			interval: time.Second,
+			healthy: func(_ context.Context) error {
+				if calls.Add(1) == 1 {
+					return ErrHealthCheckError
+				}
+				return nil
+			},
+		}
+
+		obs := &recordingObserver{results: make(map[string][]error)}
+		cfg := HealthCheckerConfig{}
+		cfg.AddServicesList([]mxtypes.HealthChecker{db})
+		cfg.SetObserver(obs)
+		svc := newHealthCheckerOpsService(quietLog(), cfg)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() { errCh <- svc.Start(ctx) }()
+
+		synctest.Wait()
+		time.Sleep(time.Second)
+		synctest.Wait()
+
+		cancel()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Start returned error: %v", err)
+		}
+
+		obs.mu.Lock()
+		defer obs.mu.Unlock()
+		got := obs.results["db"]
+		if len(got) != 2 || !errors.Is(got[0], ErrHealthCheckError) || got[1] != nil {
+			t.Fatalf("observed results = %v; want [%v <nil>]", got, ErrHealthCheckError)
+		}
+	})
+}
+
+// statusObserver collects the health statuses it is notified of.
+type statusObserver struct {
+	recordingObserver
+	statuses []error
+}
+
+func (o *statusObserver) ObserveHealthStatus(_ string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.statuses = append(o.statuses, err)
+}
+
+// The status observer gets the damped result of every poll and nothing while
+// the service is starting.
+func TestHealthChecker_Poll_NotifiesStatus(t *testing.T) {
+	failure := errors.New("down")
+	results := []error{ErrHealthCheckServiceStarting, failure, failure, nil, nil}
+
+	var calls int
+	db := &fakeHealthChecker{
+		name: "db",
+		healthy: func(context.Context) error {
+			err := results[calls]
+			calls++
+			return err
+		},
+	}
+
+	obs := &statusObserver{recordingObserver: recordingObserver{results: make(map[string][]error)}}
+	cfg := HealthCheckerConfig{FailureThreshold: 2, SuccessThreshold: 2}
+	cfg.SetObserver(obs)
+	svc := newHealthCheckerOpsService(quietLog(), cfg)
+	for range results {
+		svc.poll(t.Context(), db)
+	}
+
+	// the raw results still reach ObserveHealthCheck
+	if got := obs.results["db"]; len(got) != len(results) {
+		t.Errorf("observed results = %v; want all %d", got, len(results))
+	}
+
+	// the first failure keeps the starting status below the threshold, the
+	// first success keeps the failing one
+	want := []error{failure, failure, nil}
+	if len(obs.statuses) != len(want) {
+		t.Fatalf("statuses = %v; want %v", obs.statuses, want)
+	}
+	for i, err := range obs.statuses {
+		if !errors.Is(err, want[i]) || (err == nil) != (want[i] == nil) {
+			t.Errorf("status %d = %v; want %v", i, err, want[i])
+		}
+	}
+}
+
 func TestHealthChecker_Start_NoCheckers(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{})
@@ -423,6 +756,69 @@ func TestHealthChecker_Start_NoCheckers(t *testing.T) {
 	})
 }
 
+// With a services registry, pollers and probe lists follow services that are
+// added or removed at runtime.
+func TestHealthChecker_Start_FollowsRegistry(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		reg := newFakeRegistry()
+		cfg := HealthCheckerConfig{}
+		cfg.SetServicesRegistry(reg)
+		svc := newHealthCheckerOpsService(quietLog(), cfg)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() { errCh <- svc.Start(ctx) }()
+		synctest.Wait()
+
+		var calls atomic.Int32
+		tenant := &fakeHealthChecker{
+			name:     "tenant",
+			interval: time.Second,
+			healthy:  func(context.Context) error { calls.Add(1); return nil },
+		}
+		reg.set(
+			[]mxtypes.HealthChecker{tenant},
+			[]mxtypes.StateProvider{fakeStateProvider{name: "tenant", state: mxtypes.ServiceStateRunning}},
+		)
+		synctest.Wait()
+
+		if got := loadCode(t, svc, "tenant"); got != HealthCheckCodeOk {
+			t.Fatalf("tenant health = %v; want ok", got)
+		}
+
+		rec := httptest.NewRecorder()
+		svc.serveLiveness(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+		var body struct {
+			Services map[string]string `json:"services"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode /livez: %v", err)
+		}
+		if body.Services["tenant"] != "running" {
+			t.Fatalf("/livez services = %v; want tenant running", body.Services)
+		}
+
+		reg.set(nil, nil)
+		synctest.Wait()
+
+		if _, ok := svc.resp.Load("tenant"); ok {
+			t.Fatal("removed checker is still reported")
+		}
+		before := calls.Load()
+		time.Sleep(3 * time.Second)
+		synctest.Wait()
+		if calls.Load() != before {
+			t.Fatal("removed checker is still polled")
+		}
+
+		cancel()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Start returned error: %v", err)
+		}
+	})
+}
+
 // errResponseWriter fails every Write, to exercise JSON encode-error branches.
 type errResponseWriter struct{ header http.Header }
 
@@ -435,15 +831,388 @@ func (w *errResponseWriter) Header() http.Header {
 func (w *errResponseWriter) Write([]byte) (int, error) { return 0, errTestWrite }
 func (w *errResponseWriter) WriteHeader(int)           {}
 
+func TestHealthChecker_CriticalAndTags(t *testing.T) {
+	cfg := HealthCheckerConfig{}
+	cfg.AddStateList([]mxtypes.StateProvider{
+		fakeStateProvider{name: "db", state: mxtypes.ServiceStateRunning},
+		fakeStateProvider{name: "cache", state: mxtypes.ServiceStateRunning},
+		fakeStateProvider{name: "worker", state: mxtypes.ServiceStateRunning},
+	})
+
+	tests := []struct {
+		name         string
+		db, cache    HealthCheckCode
+		target       string
+		wantCode     int
+		wantStat     string
+		wantServices []string
+	}{
+		{"all ok", HealthCheckCodeOk, HealthCheckCodeOk, "/readyz", http.StatusOK, "ok", []string{"cache", "db", "worker"}},
+		{"non-critical error degrades", HealthCheckCodeOk, HealthCheckCodeError, "/readyz", http.StatusOK, "degraded", []string{"cache", "db", "worker"}},
+		{"non-critical starting is ignored", HealthCheckCodeOk, HealthCheckCodeServiceStarting, "/readyz", http.StatusOK, "ok", []string{"cache", "db", "worker"}},
+		{"critical error fails", HealthCheckCodeError, HealthCheckCodeOk, "/readyz", http.StatusServiceUnavailable, "unavailable", []string{"cache", "db", "worker"}},
+		{"tag filter skips other checks", HealthCheckCodeError, HealthCheckCodeOk, "/readyz?tag=cache", http.StatusOK, "ok", []string{"cache"}},
+		{"any of several tags", HealthCheckCodeOk, HealthCheckCodeOk, "/readyz?tag=cache&tag=postgres", http.StatusOK, "ok", []string{"cache", "db"}},
+		{"unknown tag", HealthCheckCodeError, HealthCheckCodeError, "/readyz?tag=external", http.StatusOK, "ok", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newHealthCheckerOpsService(quietLog(), cfg)
+			svc.resp.Store("db", healthCheckResult{code: tt.db, tags: []string{"postgres", "storage"}})
+			svc.resp.Store("cache", healthCheckResult{code: tt.cache, nonCritical: true, tags: []string{"cache"}})
+
+			rec := httptest.NewRecorder()
+			svc.serveReadiness(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d; want %d", rec.Code, tt.wantCode)
+			}
+			var body struct {
+				Status   string                           `json:"status"`
+				Services map[string]readinessServiceEntry `json:"services"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if body.Status != tt.wantStat {
+				t.Errorf("status = %q; want %q", body.Status, tt.wantStat)
+			}
+			got := slices.Sorted(maps.Keys(body.Services))
+			if !slices.Equal(got, tt.wantServices) {
+				t.Errorf("services = %v; want %v", got, tt.wantServices)
+			}
+		})
+	}
+}
+
+func TestHealthChecker_ServeHTTP_CriticalAndTags(t *testing.T) {
+	svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{})
+	svc.resp.Store("db", healthCheckResult{code: HealthCheckCodeOk, tags: []string{"db"}})
+	svc.resp.Store("cache", healthCheckResult{code: HealthCheckCodeError, nonCritical: true, tags: []string{"cache"}})
+
+	rec := httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthy", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d; want 200 with a failing non-critical check", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	svc.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthy?tag=db", nil))
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, ok := body["cache"]; ok || len(body) != 1 {
+		t.Errorf("?tag=db body = %v; want only db", body)
+	}
+}
+
+// The classification of a checker is taken from mxtypes.HealthCheckClassifier.
+func TestHealthChecker_Poll_Classification(t *testing.T) {
+	checker := &classifiedChecker{fakeHealthChecker: fakeHealthChecker{name: "cache"}}
+
+	svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{})
+	svc.poll(t.Context(), checker)
+
+	v, _ := svc.resp.Load("cache")
+	res, _ := v.(healthCheckResult)
+	if !res.nonCritical || !slices.Equal(res.tags, []string{"cache"}) {
+		t.Fatalf("result = %+v; want non-critical with tag cache", res)
+	}
+}
+
+// classifiedChecker is a non-critical fakeHealthChecker tagged "cache".
+type classifiedChecker struct{ fakeHealthChecker }
+
+func (classifiedChecker) HealthCheckCritical() bool { return false }
+func (classifiedChecker) HealthCheckTags() []string { return []string{"cache"} }
+
+// detailedHealthChecker is a fakeHealthChecker that reports details.
+type detailedHealthChecker struct {
+	fakeHealthChecker
+	details map[string]any
+}
+
+func (d *detailedHealthChecker) HealthDetails() map[string]any { return d.details }
+
+var _ mxtypes.HealthDetailer = (*detailedHealthChecker)(nil)
+
+func TestHealthChecker_Poll_RecordsResult(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		errDown := errors.New("connection refused")
+		var fail atomic.Bool
+		fail.Store(true)
+
+		db := &detailedHealthChecker{
+			fakeHealthChecker: fakeHealthChecker{
+				name: "db",
+				healthy: func(context.Context) error {
+					time.Sleep(10 * time.Millisecond)
+					if fail.Load() {
+						return errDown
+					}
+					return nil
+				},
+			},
+			details: map[string]any{"open_connections": 3},
+		}
+
+		svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{})
+		load := func() healthCheckResult {
+			v, _ := svc.resp.Load("db")
+			res, _ := v.(healthCheckResult)
+			return res
+		}
+
+		svc.poll(t.Context(), db)
+		svc.poll(t.Context(), db)
+
+		res := load()
+		if res.code != HealthCheckCodeError || res.consecutiveFailures != 2 || !errors.Is(res.lastErr, errDown) {
+			t.Fatalf("after two failures: %+v; want error code, 2 failures, last error %v", res, errDown)
+		}
+		if !res.lastSuccess.IsZero() || res.duration != 10*time.Millisecond || res.details["open_connections"] != 3 {
+			t.Fatalf("after two failures: %+v; want no success, 10ms duration and details", res)
+		}
+
+		fail.Store(false)
+		svc.poll(t.Context(), db)
+
+		res = load()
+		if res.code != HealthCheckCodeOk || res.consecutiveFailures != 0 || res.lastSuccess.IsZero() {
+			t.Fatalf("after recovery: %+v; want ok code, no failures and a success time", res)
+		}
+		if !errors.Is(res.lastErr, errDown) {
+			t.Errorf("after recovery last error = %v; want it kept", res.lastErr)
+		}
+	})
+}
+
+// policyHealthChecker is a fakeHealthChecker with its own policy.
+type policyHealthChecker struct {
+	fakeHealthChecker
+	policy mxtypes.HealthCheckPolicy
+}
+
+func (p *policyHealthChecker) HealthCheckPolicy() mxtypes.HealthCheckPolicy { return p.policy }
+
+var _ mxtypes.HealthCheckPolicyProvider = (*policyHealthChecker)(nil)
+
+func TestHealthCheckerConfig_Policy(t *testing.T) {
+	cfg := HealthCheckerConfig{CheckTimeout: time.Second, FailureThreshold: 3}
+
+	got := cfg.policy(&fakeHealthChecker{name: "a"})
+	want := mxtypes.HealthCheckPolicy{Timeout: time.Second, FailureThreshold: 3, SuccessThreshold: 1}
+	if got != want {
+		t.Errorf("default policy = %+v; want %+v", got, want)
+	}
+
+	got = cfg.policy(&policyHealthChecker{
+		fakeHealthChecker: fakeHealthChecker{name: "b"},
+		policy:            mxtypes.HealthCheckPolicy{Timeout: time.Minute, SuccessThreshold: 2},
+	})
+	want = mxtypes.HealthCheckPolicy{Timeout: time.Minute, FailureThreshold: 3, SuccessThreshold: 2}
+	if got != want {
+		t.Errorf("checker policy = %+v; want %+v", got, want)
+	}
+}
+
+// The reported code flips only after FailureThreshold consecutive failures and
+// back after SuccessThreshold consecutive successes.
+func TestHealthChecker_Poll_Thresholds(t *testing.T) {
+	var fail atomic.Bool
+	checker := &fakeHealthChecker{
+		name: "db",
+		healthy: func(context.Context) error {
+			if fail.Load() {
+				return ErrHealthCheckError
+			}
+			return nil
+		},
+	}
+
+	svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{FailureThreshold: 3, SuccessThreshold: 2})
+
+	steps := []struct {
+		fail bool
+		want HealthCheckCode
+	}{
+		{false, HealthCheckCodeOk},
+		{true, HealthCheckCodeOk},
+		{true, HealthCheckCodeOk},
+		{false, HealthCheckCodeOk}, // a success resets the failure streak
+		{true, HealthCheckCodeOk},
+		{true, HealthCheckCodeOk},
+		{true, HealthCheckCodeError},
+		{false, HealthCheckCodeError},
+		{true, HealthCheckCodeError},
+		{false, HealthCheckCodeError},
+		{false, HealthCheckCodeOk},
+	}
+	for i, step := range steps {
+		fail.Store(step.fail)
+		svc.poll(t.Context(), checker)
+		if got := loadCode(t, svc, "db"); got != step.want {
+			t.Fatalf("step %d (fail=%v): code = %v; want %v", i, step.fail, got, step.want)
+		}
+	}
+}
+
+// A checker that ignores its context is abandoned after the timeout and the
+// check counts as failed.
+func TestHealthChecker_Poll_Timeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		hanging := &policyHealthChecker{
+			fakeHealthChecker: fakeHealthChecker{
+				name: "hanging",
+				healthy: func(context.Context) error {
+					<-release
+					return nil
+				},
+			},
+			policy: mxtypes.HealthCheckPolicy{Timeout: time.Second},
+		}
+
+		svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{CheckTimeout: time.Hour})
+
+		started := time.Now()
+		svc.poll(t.Context(), hanging)
+		if elapsed := time.Since(started); elapsed != time.Second {
+			t.Fatalf("poll took %s; want the 1s checker timeout", elapsed)
+		}
+
+		v, _ := svc.resp.Load("hanging")
+		res, _ := v.(healthCheckResult)
+		if res.code != HealthCheckCodeError || !errors.Is(res.lastErr, ErrHealthCheckTimeout) {
+			t.Fatalf("result = %+v; want error code with ErrHealthCheckTimeout", res)
+		}
+	})
+}
+
+// A config built in code without CheckTimeout still bounds the checks.
+func TestHealthChecker_DefaultCheckTimeout(t *testing.T) {
+	svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{})
+	if got := svc.config.policy(&fakeHealthChecker{name: "db"}).Timeout; got != 5*time.Second {
+		t.Errorf("timeout = %s; want 5s by default", got)
+	}
+}
+
+// A checker hanging past its timeout is not called again until it returns,
+// so the abandoned calls do not pile up.
+func TestHealthChecker_Poll_HangingNotStacked(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+
+		hanging := &fakeHealthChecker{
+			name: "hanging",
+			healthy: func(context.Context) error {
+				calls.Add(1)
+				<-release
+				return nil
+			},
+		}
+
+		svc := newHealthCheckerOpsService(quietLog(), HealthCheckerConfig{CheckTimeout: time.Second})
+		for range 5 {
+			svc.poll(t.Context(), hanging)
+		}
+		if calls.Load() != 1 {
+			t.Fatalf("calls = %d; want 1 while the first one hangs", calls.Load())
+		}
+		if got := loadCode(t, svc, "hanging"); got != HealthCheckCodeError {
+			t.Errorf("code = %v; want error", got)
+		}
+
+		// once the hanging call returned, the checker is called again
+		close(release)
+		synctest.Wait()
+		svc.poll(t.Context(), hanging)
+		if calls.Load() != 2 {
+			t.Errorf("calls = %d; want a new call after the first returned", calls.Load())
+		}
+	})
+}
+
+func TestHealthChecker_VerboseOutput(t *testing.T) {
+	cfg := HealthCheckerConfig{}
+	cfg.AddStateList([]mxtypes.StateProvider{fakeStateProvider{name: "db", state: mxtypes.ServiceStateRunning}})
+	svc := newHealthCheckerOpsService(quietLog(), cfg)
+	svc.resp.Store("db", healthCheckResult{
+		code:                HealthCheckCodeError,
+		lastErr:             errors.New("connection refused"),
+		duration:            time.Second,
+		consecutiveFailures: 4,
+		details:             map[string]any{"pool": "exhausted"},
+	})
+
+	tests := []struct {
+		target  string
+		handler http.HandlerFunc
+		entry   func(body map[string]any) map[string]any
+	}{
+		{
+			target:  "/healthy",
+			handler: svc.ServeHTTP,
+			entry:   func(body map[string]any) map[string]any { m, _ := body["db"].(map[string]any); return m },
+		},
+		{
+			target:  "/readyz",
+			handler: svc.serveReadiness,
+			entry: func(body map[string]any) map[string]any {
+				services, _ := body["services"].(map[string]any)
+				m, _ := services["db"].(map[string]any)
+				return m
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			get := func(target string) map[string]any {
+				rec := httptest.NewRecorder()
+				tt.handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+				if rec.Code != http.StatusServiceUnavailable {
+					t.Fatalf("GET %s status = %d; want 503", target, rec.Code)
+				}
+				var body map[string]any
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				return body
+			}
+
+			// the default output does not reveal the error
+			for _, target := range []string{tt.target, tt.target + "?verbose=false"} {
+				if entry := tt.entry(get(target)); entry != nil && entry["last_error"] != nil {
+					t.Errorf("GET %s reveals the error: %v", target, entry)
+				}
+			}
+
+			for _, target := range []string{tt.target + "?verbose", tt.target + "?verbose=1"} {
+				entry := tt.entry(get(target))
+				if entry["last_error"] != "connection refused" || entry["duration"] != "1s" ||
+					entry["consecutive_failures"] != float64(4) || entry["details"] == nil {
+					t.Errorf("GET %s entry = %v; want error, duration, failures and details", target, entry)
+				}
+			}
+		})
+	}
+}
+
 func TestHealthChecker_ServeReadiness_SkipsMalformedRespEntries(t *testing.T) {
 	cfg := HealthCheckerConfig{}
 	cfg.AddStateList([]mxtypes.StateProvider{fakeStateProvider{name: "a", state: mxtypes.ServiceStateRunning}})
 	svc := newHealthCheckerOpsService(quietLog(), cfg)
 
-	// Defensive branches: non-string key and non-HealthCheckCode value are ignored.
-	svc.resp.Store(42, HealthCheckCodeOk)
+	// Defensive branches: non-string key and non-healthCheckResult value are ignored.
+	svc.resp.Store(42, healthCheckResult{code: HealthCheckCodeOk})
 	svc.resp.Store("weird", "not-a-code")
-	svc.resp.Store("a", HealthCheckCodeOk)
+	svc.resp.Store("a", healthCheckResult{code: HealthCheckCodeOk})
 
 	rec := httptest.NewRecorder()
 	svc.serveReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
@@ -469,7 +1238,7 @@ func TestHealthChecker_EncodeErrors(t *testing.T) {
 	cfg := HealthCheckerConfig{}
 	cfg.AddStateList([]mxtypes.StateProvider{fakeStateProvider{name: "a", state: mxtypes.ServiceStateRunning}})
 	svc := newHealthCheckerOpsService(quietLog(), cfg)
-	svc.resp.Store("a", HealthCheckCodeOk)
+	svc.resp.Store("a", healthCheckResult{code: HealthCheckCodeOk})
 
 	// Each handler must not panic when the response writer fails.
 	svc.ServeHTTP(&errResponseWriter{}, httptest.NewRequest(http.MethodGet, "/healthy", nil))
@@ -483,9 +1252,9 @@ func loadCode(t *testing.T, svc *healthCheckerOpsService, name string) HealthChe
 	if !ok {
 		t.Fatalf("no health code stored for %q", name)
 	}
-	code, ok := v.(HealthCheckCode)
+	res, ok := v.(healthCheckResult)
 	if !ok {
-		t.Fatalf("stored value for %q is not a HealthCheckCode: %T", name, v)
+		t.Fatalf("stored value for %q is not a healthCheckResult: %T", name, v)
 	}
-	return code
+	return res.code
 }
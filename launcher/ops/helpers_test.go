@@ -3,6 +3,8 @@ package ops
 import (
 	"context"
 	"errors"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/tkcrm/mx/logger"
@@ -46,3 +48,42 @@ func (f fakeStateProvider) Name() string                { return f.name }
 func (f fakeStateProvider) State() mxtypes.ServiceState { return f.state }
 
 var _ mxtypes.StateProvider = (*fakeStateProvider)(nil)
+
+// fakeRegistry is a mutable ServicesRegistry.
+type fakeRegistry struct {
+	mu       sync.Mutex
+	checkers []mxtypes.HealthChecker
+	states   []mxtypes.StateProvider
+	changed  chan struct{}
+}
+
+func newFakeRegistry() *fakeRegistry { return &fakeRegistry{changed: make(chan struct{})} }
+
+func (r *fakeRegistry) HealthCheckers() []mxtypes.HealthChecker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.checkers)
+}
+
+func (r *fakeRegistry) StateProviders() []mxtypes.StateProvider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.states)
+}
+
+func (r *fakeRegistry) Changed() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changed
+}
+
+// set replaces the registered services and notifies subscribers.
+func (r *fakeRegistry) set(checkers []mxtypes.HealthChecker, states []mxtypes.StateProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers, r.states = checkers, states
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+var _ ServicesRegistry = (*fakeRegistry)(nil)
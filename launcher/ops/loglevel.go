@@ -0,0 +1,185 @@
+package ops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+	"github.com/tkcrm/mx/transport/http_transport"
+)
+
+// LogLevelConfig configures the endpoint changing the log level at runtime,
+// e.g. to debug an issue without a restart. It is served only with basic auth
+// enabled.
+type LogLevelConfig struct {
+	Enabled   bool                           `default:"false" usage:"allows to enable the log level endpoint" example:"false"`
+	Path      string                         `default:"/loglevel" validate:"required" usage:"allows to set custom log level path" example:"/loglevel"`
+	Port      string                         `default:"10000" validate:"required" usage:"allows to set custom log level port" example:"10000"`
+	BasicAuth http_transport.BasicAuthConfig `yaml:"basic_auth"`
+}
+
+type logLevelOpsService struct {
+	log    logger.ExtendedLogger
+	config LogLevelConfig
+
+	// configured is the level the logger had on start, restored once the TTL
+	// of a change expires.
+	configured logger.LogLevel
+
+	mu       sync.Mutex
+	revert   *time.Timer
+	revertAt time.Time
+	// changes counts the level changes, so a revert scheduled before the
+	// last change is ignored.
+	changes int
+}
+
+func newLogLevelOpsService(log logger.ExtendedLogger, cfg LogLevelConfig) *logLevelOpsService {
+	if cfg.Path == "" {
+		cfg.Path = "/loglevel"
+	}
+	return &logLevelOpsService{log: log, config: cfg, configured: log.Level()}
+}
+
+func (s *logLevelOpsService) Name() string { return "loglevel" }
+
+func (s *logLevelOpsService) getEnabled() bool { return s.config.Enabled }
+
+func (s *logLevelOpsService) getPort() string { return s.config.Port }
+
+func (s *logLevelOpsService) getHTTPOptions() []http_transport.Option {
+	res := make([]http_transport.Option, 0)
+	return res
+}
+
+func (s *logLevelOpsService) initService(mux *http.ServeMux) {
+	if !s.config.BasicAuth.Enabled {
+		s.log.Errorf("log level endpoint is enabled without basic auth, it is not served")
+		return
+	}
+
+	mux.Handle("GET "+s.config.Path, http_transport.BasicAuthHandler(http.HandlerFunc(s.serveGet), s.config.BasicAuth))
+	mux.Handle("PUT "+s.config.Path, http_transport.BasicAuthHandler(http.HandlerFunc(s.servePut), s.config.BasicAuth))
+}
+
+type logLevelRequest struct {
+	Level logger.LogLevel `json:"level"`
+	// TTL reverts the level to the configured one once it elapses, e.g.
+	// "10m". Empty keeps the level until the next change.
+	TTL string `json:"ttl,omitempty"`
+}
+
+type logLevelResponse struct {
+	Level      logger.LogLevel `json:"level"`
+	Configured logger.LogLevel `json:"configured"`
+	RevertAt   *time.Time      `json:"revert_at,omitempty"`
+}
+
+// serveGet returns the current and the configured log level.
+func (s *logLevelOpsService) serveGet(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.state())
+}
+
+// servePut changes the log level, for the TTL if given.
+func (s *logLevelOpsService) servePut(w http.ResponseWriter, r *http.Request) {
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			s.writeError(w, fmt.Errorf("invalid ttl: %s", req.TTL))
+			return
+		}
+		ttl = d
+	}
+
+	if err := s.set(req.Level, ttl); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, s.state())
+}
+
+// set changes the log level and schedules its revert after ttl, if positive.
+// A change cancels the revert scheduled by the previous one.
+func (s *logLevelOpsService) set(level logger.LogLevel, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.log.Level()
+	if err := s.log.SetLevel(level); err != nil {
+		return err
+	}
+
+	s.changes++
+	if s.revert != nil {
+		s.revert.Stop()
+		s.revert, s.revertAt = nil, time.Time{}
+	}
+
+	if ttl <= 0 {
+		s.log.Infof("log level changed from %s to %s", prev, s.log.Level())
+		return nil
+	}
+
+	change := s.changes
+	s.revert = time.AfterFunc(ttl, func() { s.expire(change) })
+	s.revertAt = time.Now().Add(ttl)
+	s.log.Infof("log level changed from %s to %s for %s", prev, s.log.Level(), ttl)
+
+	return nil
+}
+
+// expire restores the configured log level, unless the level was changed
+// again after change.
+func (s *logLevelOpsService) expire(change int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.changes != change {
+		return
+	}
+	s.revert, s.revertAt = nil, time.Time{}
+
+	if err := s.log.SetLevel(s.configured); err != nil {
+		s.log.Errorf("failed to revert log level: %s", err)
+		return
+	}
+	s.log.Infof("log level reverted to %s", s.configured)
+}
+
+func (s *logLevelOpsService) state() logLevelResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := logLevelResponse{Level: s.log.Level(), Configured: s.configured}
+	if s.revert != nil {
+		revertAt := s.revertAt
+		res.RevertAt = &revertAt
+	}
+	return res
+}
+
+func (s *logLevelOpsService) writeError(w http.ResponseWriter, err error) {
+	s.writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+}
+
+func (s *logLevelOpsService) writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Errorf("loglevel: could not write response: %s", err)
+	}
+}
+
+var _ opsService = (*logLevelOpsService)(nil)
@@ -0,0 +1,144 @@
+package ops
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+	"github.com/tkcrm/mx/transport/http_transport"
+)
+
+// logLevelAuth is the basic auth of the log level endpoint in tests.
+var logLevelAuth = http_transport.BasicAuthConfig{Enabled: true, Username: "ops", Password: "secret"}
+
+// newLogLevelMux returns a mux serving the log level endpoint of log.
+func newLogLevelMux(log logger.ExtendedLogger, auth http_transport.BasicAuthConfig) *http.ServeMux {
+	mux := http.NewServeMux()
+	newLogLevelOpsService(log, LogLevelConfig{Enabled: true, BasicAuth: auth}).initService(mux)
+	return mux
+}
+
+// serveLogLevel serves an authenticated request and decodes the response.
+func serveLogLevel(t *testing.T, mux *http.ServeMux, method, body string) logLevelResponse {
+	t.Helper()
+
+	rec := serveLogLevelRaw(mux, method, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s status = %d; want 200: %s", method, rec.Code, rec.Body)
+	}
+
+	var res logLevelResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res
+}
+
+// serveLogLevelRaw serves an authenticated request.
+func serveLogLevelRaw(mux *http.ServeMux, method, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/loglevel", strings.NewReader(body))
+	req.SetBasicAuth(logLevelAuth.Username, logLevelAuth.Password)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLogLevel_GetPut(t *testing.T) {
+	log := quietLog()
+	mux := newLogLevelMux(log, logLevelAuth)
+
+	res := serveLogLevel(t, mux, http.MethodGet, "")
+	if res.Level != logger.LogLevelFatal || res.Configured != logger.LogLevelFatal || res.RevertAt != nil {
+		t.Errorf("GET = %+v; want the configured level", res)
+	}
+
+	res = serveLogLevel(t, mux, http.MethodPut, `{"level":"error"}`)
+	if res.Level != logger.LogLevelError || res.Configured != logger.LogLevelFatal || res.RevertAt != nil {
+		t.Errorf("PUT = %+v; want error until the next change", res)
+	}
+	if log.Level() != logger.LogLevelError {
+		t.Errorf("logger level = %s; want error", log.Level())
+	}
+
+	for _, body := range []string{`{"level":"verbose"}`, `{"level":"debug","ttl":"soon"}`, `{"level":"debug","ttl":"-1m"}`, `level=debug`} {
+		if rec := serveLogLevelRaw(mux, http.MethodPut, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s status = %d; want 400", body, rec.Code)
+		}
+	}
+	if log.Level() != logger.LogLevelError {
+		t.Errorf("logger level = %s; want error kept after invalid requests", log.Level())
+	}
+}
+
+func TestLogLevel_TTL(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		log := quietLog()
+		mux := newLogLevelMux(log, logLevelAuth)
+
+		res := serveLogLevel(t, mux, http.MethodPut, `{"level":"error","ttl":"10m"}`)
+		if res.RevertAt == nil || !res.RevertAt.Equal(time.Now().Add(10*time.Minute)) {
+			t.Errorf("revert_at = %v; want in 10m", res.RevertAt)
+		}
+
+		// a later change replaces the pending revert
+		time.Sleep(5 * time.Minute)
+		serveLogLevel(t, mux, http.MethodPut, `{"level":"panic","ttl":"10m"}`)
+
+		time.Sleep(6 * time.Minute)
+		synctest.Wait()
+		if log.Level() != logger.LogLevelPanic {
+			t.Fatalf("level = %s; want panic until its own TTL expires", log.Level())
+		}
+
+		time.Sleep(5 * time.Minute)
+		synctest.Wait()
+		res = serveLogLevel(t, mux, http.MethodGet, "")
+		if res.Level != logger.LogLevelFatal || res.RevertAt != nil {
+			t.Errorf("GET = %+v; want the configured level restored", res)
+		}
+
+		// a change without TTL cancels the revert
+		serveLogLevel(t, mux, http.MethodPut, `{"level":"error","ttl":"1m"}`)
+		serveLogLevel(t, mux, http.MethodPut, `{"level":"warn"}`)
+		time.Sleep(2 * time.Minute)
+		synctest.Wait()
+		if log.Level() != logger.LogLevelWarn {
+			t.Errorf("level = %s; want warn kept", log.Level())
+		}
+	})
+}
+
+func TestLogLevel_BasicAuth(t *testing.T) {
+	log := quietLog()
+	mux := newLogLevelMux(log, logLevelAuth)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without credentials = %d; want 401", rec.Code)
+	}
+	if log.Level() != logger.LogLevelFatal {
+		t.Errorf("level = %s; want unchanged", log.Level())
+	}
+}
+
+// Without basic auth the log level endpoint is not served at all.
+func TestLogLevel_WithoutBasicAuth(t *testing.T) {
+	log := quietLog()
+	mux := newLogLevelMux(log, http_transport.BasicAuthConfig{})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d; want 404 without basic auth", rec.Code)
+	}
+	if log.Level() != logger.LogLevelFatal {
+		t.Errorf("level = %s; want unchanged", log.Level())
+	}
+}
@@ -1,9 +1,15 @@
 package ops
 
 import (
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/tkcrm/mx/transport/http_transport"
 )
 
@@ -20,6 +26,73 @@ type MetricsConfig struct {
 	Path      string                         `default:"/metrics" validate:"required" usage:"allows to set custom metrics path" example:"/metrics"`
 	Port      string                         `default:"10000" validate:"required" usage:"allows to set custom metrics port" example:"10000"`
 	BasicAuth http_transport.BasicAuthConfig `yaml:"basic_auth"`
+
+	// GoCollector and ProcessCollector register the Go runtime and process
+	// collectors. The default registry always has both.
+	GoCollector      bool `yaml:"go_collector" default:"false" usage:"allows to register the Go runtime collector in a custom registry" example:"true"`
+	ProcessCollector bool `yaml:"process_collector" default:"false" usage:"allows to register the process collector in a custom registry" example:"true"`
+
+	// ConstLabels are added to every exposed series that does not have a
+	// label of the same name yet.
+	ConstLabels map[string]string `yaml:"const_labels" usage:"allows to add constant labels to all metrics"`
+
+	// OpenMetrics enables the OpenMetrics exposition format when the scraper
+	// negotiates it. Exemplars are only exposed in this format.
+	OpenMetrics bool `yaml:"open_metrics" default:"false" usage:"allows to enable OpenMetrics exposition with exemplars" example:"true"`
+
+	// Registerer and Gatherer replace the default Prometheus registry, e.g. to
+	// give every launcher in a process its own *prometheus.Registry. If only
+	// one of them is set and it also implements the other interface, it is
+	// used for both.
+	Registerer prometheus.Registerer `yaml:"-" json:"-"`
+	Gatherer   prometheus.Gatherer   `yaml:"-" json:"-"`
+}
+
+// GetRegisterer returns the registerer metrics are registered with.
+func (c MetricsConfig) GetRegisterer() prometheus.Registerer {
+	if c.Registerer != nil {
+		return c.Registerer
+	}
+	if reg, ok := c.Gatherer.(prometheus.Registerer); ok {
+		return reg
+	}
+	return prometheus.DefaultRegisterer
+}
+
+// GetGatherer returns the gatherer metrics are exposed from.
+func (c MetricsConfig) GetGatherer() prometheus.Gatherer {
+	if c.Gatherer != nil {
+		return c.Gatherer
+	}
+	if g, ok := c.Registerer.(prometheus.Gatherer); ok {
+		return g
+	}
+	return prometheus.DefaultGatherer
+}
+
+// registerCollectors registers the enabled runtime collectors. Collectors
+// that are already registered are left as they are.
+func (c MetricsConfig) registerCollectors() error {
+	var cs []prometheus.Collector
+	if c.GoCollector {
+		cs = append(cs, collectors.NewGoCollector())
+	}
+	if c.ProcessCollector {
+		cs = append(cs, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	reg := c.GetRegisterer()
+	var errs error
+	for _, collector := range cs {
+		if err := reg.Register(collector); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if errors.As(err, &are) {
+				continue
+			}
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
 }
 
 func (s metricsOpsService) Name() string { return "metrics" }
@@ -34,7 +107,41 @@ func (s metricsOpsService) getHTTPOptions() []http_transport.Option {
 }
 
 func (s metricsOpsService) initService(mux *http.ServeMux) {
-	mux.Handle(s.config.Path, http_transport.BasicAuthHandler(promhttp.Handler(), s.config.BasicAuth))
+	var gatherer prometheus.Gatherer = s.config.GetGatherer()
+	if len(s.config.ConstLabels) > 0 {
+		gatherer = constLabelsGatherer{gatherer, s.config.ConstLabels}
+	}
+
+	handler := promhttp.InstrumentMetricHandler(
+		s.config.GetRegisterer(),
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: s.config.OpenMetrics,
+		}),
+	)
+
+	mux.Handle(s.config.Path, http_transport.BasicAuthHandler(handler, s.config.BasicAuth))
+}
+
+// constLabelsGatherer adds constant labels to every gathered series.
+type constLabelsGatherer struct {
+	gatherer prometheus.Gatherer
+	labels   map[string]string
+}
+
+func (g constLabelsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for name, value := range g.labels {
+				if slices.ContainsFunc(m.GetLabel(), func(lp *dto.LabelPair) bool { return lp.GetName() == name }) {
+					continue
+				}
+				m.Label = append(m.Label, &dto.LabelPair{Name: &name, Value: &value})
+			}
+			slices.SortFunc(m.Label, func(a, b *dto.LabelPair) int { return strings.Compare(a.GetName(), b.GetName()) })
+		}
+	}
+	return families, err
 }
 
 var _ opsService = (*metricsOpsService)(nil)
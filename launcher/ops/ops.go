@@ -30,6 +30,9 @@ func New(log logger.ExtendedLogger, cfg Config) []mxtypes.IService {
 	services := []opsService{}
 
 	if s.config.Metrics.Enabled {
+		if err := s.config.Metrics.registerCollectors(); err != nil {
+			s.logger.Errorf("failed to register metrics collectors: %s", err)
+		}
 		services = append(services, newMetricsOpsService(s.config.Metrics))
 	}
 
@@ -43,6 +46,14 @@ func New(log logger.ExtendedLogger, cfg Config) []mxtypes.IService {
 		services = append(services, newProfilerOpsService(s.config.Profiler))
 	}
 
+	if s.config.Admin.Enabled {
+		services = append(services, newAdminOpsService(s.logger, s.config.Admin))
+	}
+
+	if s.config.LogLevel.Enabled {
+		services = append(services, newLogLevelOpsService(s.logger, s.config.LogLevel))
+	}
+
 	if len(services) == 0 {
 		return []mxtypes.IService{}
 	}
@@ -1,10 +1,13 @@
 package ops
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tkcrm/mx/transport/http_transport"
 )
 
@@ -223,6 +226,113 @@ func TestMetricsOpsService_BasicAuth(t *testing.T) {
 	}
 }
 
+// scrape serves the metrics service and returns the response to a GET with
+// the given Accept header.
+func scrape(t *testing.T, svc *metricsOpsService, accept string) (*http.Response, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	svc.initService(mux)
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestMetricsOpsService_CustomRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "custom_total", Help: "test"}))
+
+	cfg := MetricsConfig{Enabled: true, Path: "/metrics", Port: "10000", Registerer: reg}
+	if cfg.GetGatherer() != reg {
+		t.Fatal("GetGatherer did not fall back to the registerer")
+	}
+	if err := cfg.registerCollectors(); err != nil {
+		t.Fatalf("registerCollectors: %v", err)
+	}
+
+	_, body := scrape(t, newMetricsOpsService(cfg), "")
+	if !strings.Contains(body, "custom_total 0") {
+		t.Errorf("body does not contain the custom metric:\n%s", body)
+	}
+	if strings.Contains(body, "go_goroutines") || strings.Contains(body, "process_") {
+		t.Errorf("body contains runtime metrics of disabled collectors:\n%s", body)
+	}
+}
+
+func TestMetricsOpsService_Collectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := MetricsConfig{Enabled: true, Path: "/metrics", Port: "10000", Gatherer: reg, GoCollector: true}
+
+	// registering twice must be tolerated
+	for range 2 {
+		if err := cfg.registerCollectors(); err != nil {
+			t.Fatalf("registerCollectors: %v", err)
+		}
+	}
+
+	_, body := scrape(t, newMetricsOpsService(cfg), "")
+	if !strings.Contains(body, "go_goroutines") {
+		t.Errorf("body does not contain Go runtime metrics:\n%s", body)
+	}
+}
+
+func TestMetricsOpsService_ConstLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "labelled",
+		Help:        "test",
+		ConstLabels: prometheus.Labels{"env": "own"},
+	}))
+
+	_, body := scrape(t, newMetricsOpsService(MetricsConfig{
+		Enabled:     true,
+		Path:        "/metrics",
+		Port:        "10000",
+		Registerer:  reg,
+		ConstLabels: map[string]string{"env": "prod", "region": "eu"},
+	}), "")
+
+	// labels already set on a series win
+	if want := `labelled{env="own",region="eu"} 0`; !strings.Contains(body, want) {
+		t.Errorf("body does not contain %s:\n%s", want, body)
+	}
+}
+
+func TestMetricsOpsService_OpenMetrics(t *testing.T) {
+	const accept = "application/openmetrics-text; version=1.0.0"
+
+	for _, enabled := range []bool{false, true} {
+		resp, _ := scrape(t, newMetricsOpsService(MetricsConfig{
+			Enabled:     true,
+			Path:        "/metrics",
+			Port:        "10000",
+			Registerer:  prometheus.NewRegistry(),
+			OpenMetrics: enabled,
+		}), accept)
+
+		isOpenMetrics := strings.HasPrefix(resp.Header.Get("Content-Type"), "application/openmetrics-text")
+		if isOpenMetrics != enabled {
+			t.Errorf("OpenMetrics=%v: Content-Type = %q", enabled, resp.Header.Get("Content-Type"))
+		}
+	}
+}
+
 // --- profiler service ---
 
 func TestProfilerOpsService_Getters(t *testing.T) {
@@ -0,0 +1,79 @@
+package otel
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Exporter selects where spans and metrics are sent.
+type Exporter string
+
+const (
+	// ExporterOTLPGRPC sends telemetry to an OTLP collector over gRPC.
+	ExporterOTLPGRPC Exporter = "otlp-grpc"
+	// ExporterOTLPHTTP sends telemetry to an OTLP collector over HTTP.
+	ExporterOTLPHTTP Exporter = "otlp-http"
+	// ExporterStdout writes telemetry to stdout, for local development.
+	ExporterStdout Exporter = "stdout"
+	// ExporterInMemory keeps telemetry in memory, for tests.
+	ExporterInMemory Exporter = "memory"
+)
+
+type Option func(*Config)
+
+type Config struct {
+	Enabled        bool              `default:"false"`
+	Exporter       Exporter          `default:"otlp-grpc" validate:"oneof=otlp-grpc otlp-http stdout memory" usage:"allows to set exporter: otlp-grpc/otlp-http/stdout/memory" example:"otlp-grpc"`
+	Endpoint       string            `usage:"OTLP collector endpoint. If empty, the OTLP defaults and environment variables apply." example:"localhost:4317"`
+	Insecure       bool              `default:"false" usage:"allows to disable TLS for the OTLP exporter"`
+	Headers        map[string]string `usage:"headers sent with every OTLP export request"`
+	SamplingRatio  float64           `yaml:"sampling_ratio" default:"1" usage:"fraction of root traces to sample, from 0 to 1"`
+	MetricInterval time.Duration     `yaml:"metric_interval" default:"60s" usage:"interval between metric exports"`
+
+	serviceName    string
+	serviceVersion string
+	attributes     []attribute.KeyValue
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.serviceName == "" {
+		return errors.New("service name is required")
+	}
+
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Exporter, validation.Required, validation.In(ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterStdout, ExporterInMemory)),
+		validation.Field(&c.SamplingRatio, validation.Min(0.0), validation.Max(1.0)),
+		validation.Field(&c.MetricInterval, validation.Min(time.Duration(0))),
+	)
+}
+
+// WithServiceName sets the service.name resource attribute, usually the
+// launcher name.
+func WithServiceName(name string) Option {
+	return func(c *Config) {
+		c.serviceName = name
+	}
+}
+
+// WithServiceVersion sets the service.version resource attribute, usually the
+// launcher version.
+func WithServiceVersion(version string) Option {
+	return func(c *Config) {
+		c.serviceVersion = version
+	}
+}
+
+// WithAttributes adds attributes to the resource describing the service.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *Config) {
+		c.attributes = append(c.attributes, attrs...)
+	}
+}
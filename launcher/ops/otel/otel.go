@@ -0,0 +1,232 @@
+// Package otel bootstraps OpenTelemetry tracing and metrics: it builds a
+// TracerProvider and a MeterProvider for the configured exporter, installs them
+// as the global providers and flushes them when stopped. Provider implements
+// the mx service interface, so it is registered with the launcher like any other
+// service and shut down during launcher stop.
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+// Provider owns the tracer and meter providers.
+type Provider struct {
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+
+	// set for ExporterInMemory only
+	spans  *tracetest.InMemoryExporter
+	reader *sdkmetric.ManualReader
+}
+
+// New builds the tracer and meter providers and installs them, together with
+// the W3C trace context and baggage propagators, as the OpenTelemetry globals.
+func New(ctx context.Context, cfg Config, opts ...Option) (*Provider, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("otel is not enabled")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("validate otel config error: %w", err)
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init otel resource error: %w", err)
+	}
+
+	p := new(Provider)
+
+	spanProcessor, err := p.newSpanProcessor(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init otel trace exporter error: %w", err)
+	}
+
+	reader, err := p.newMetricReader(ctx, cfg)
+	if err != nil {
+		_ = spanProcessor.Shutdown(ctx)
+		return nil, fmt.Errorf("init otel metric exporter error: %w", err)
+	}
+
+	p.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplingRatio))),
+		sdktrace.WithSpanProcessor(spanProcessor),
+	)
+	p.meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(reader),
+	)
+
+	otel.SetTracerProvider(p.tracerProvider)
+	otel.SetMeterProvider(p.meterProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return p, nil
+}
+
+func newResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	attrs := []resource.Option{
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.serviceName)),
+		resource.WithAttributes(cfg.attributes...),
+	}
+	if cfg.serviceVersion != "" {
+		attrs = append(attrs, resource.WithAttributes(semconv.ServiceVersion(cfg.serviceVersion)))
+	}
+
+	return resource.New(ctx, attrs...)
+}
+
+func (p *Provider) newSpanProcessor(ctx context.Context, cfg Config) (sdktrace.SpanProcessor, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch cfg.Exporter {
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	case ExporterInMemory:
+		// spans are exported synchronously, so they are visible at once
+		p.spans = tracetest.NewInMemoryExporter()
+		return sdktrace.NewSimpleSpanProcessor(p.spans), nil
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewBatchSpanProcessor(exporter), nil
+}
+
+func (p *Provider) newMetricReader(ctx context.Context, cfg Config) (sdkmetric.Reader, error) {
+	var (
+		exporter sdkmetric.Exporter
+		err      error
+	)
+
+	switch cfg.Exporter {
+	case ExporterOTLPGRPC:
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		exporter, err = otlpmetricgrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		exporter, err = otlpmetrichttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdoutmetric.New()
+	case ExporterInMemory:
+		// metrics are collected on demand, see MetricReader
+		p.reader = sdkmetric.NewManualReader()
+		return p.reader, nil
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []sdkmetric.PeriodicReaderOption
+	if cfg.MetricInterval > 0 {
+		opts = append(opts, sdkmetric.WithInterval(cfg.MetricInterval))
+	}
+
+	return sdkmetric.NewPeriodicReader(exporter, opts...), nil
+}
+
+// TracerProvider returns the installed tracer provider.
+func (p *Provider) TracerProvider() *sdktrace.TracerProvider { return p.tracerProvider }
+
+// MeterProvider returns the installed meter provider.
+func (p *Provider) MeterProvider() *sdkmetric.MeterProvider { return p.meterProvider }
+
+// SpanExporter returns the in-memory span exporter, or nil unless the
+// exporter is ExporterInMemory.
+func (p *Provider) SpanExporter() *tracetest.InMemoryExporter { return p.spans }
+
+// MetricReader returns the manual reader to collect metrics from, or nil
+// unless the exporter is ExporterInMemory.
+func (p *Provider) MetricReader() *sdkmetric.ManualReader { return p.reader }
+
+// Name returns the service name.
+func (p *Provider) Name() string { return "otel" }
+
+// Start blocks until ctx is done. The providers are already running once New
+// returns, so telemetry from other services is recorded from the start.
+func (p *Provider) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Stop flushes the pending spans and metrics and shuts the providers down.
+func (p *Provider) Stop(ctx context.Context) error {
+	var errs error
+	if err := p.tracerProvider.ForceFlush(ctx); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("flush traces error: %w", err))
+	}
+	if err := p.tracerProvider.Shutdown(ctx); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("shutdown tracer provider error: %w", err))
+	}
+	if err := p.meterProvider.ForceFlush(ctx); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("flush metrics error: %w", err))
+	}
+	if err := p.meterProvider.Shutdown(ctx); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("shutdown meter provider error: %w", err))
+	}
+	return errs
+}
@@ -0,0 +1,139 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	mxotel "github.com/tkcrm/mx/ops/otel"
+)
+
+func TestNew_InMemory(t *testing.T) {
+	p, err := mxotel.New(t.Context(),
+		mxotel.Config{Enabled: true, Exporter: mxotel.ExporterInMemory, SamplingRatio: 1},
+		mxotel.WithServiceName("app"),
+		mxotel.WithServiceVersion("v1.2.3"),
+		mxotel.WithAttributes(attribute.String("deployment.environment", "test")),
+	)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	// the providers are installed globally
+	_, span := otel.Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	counter, err := otel.Meter("test").Int64Counter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(context.Background(), 3)
+
+	spans := p.SpanExporter().GetSpans()
+	if len(spans) != 1 || spans[0].Name != "op" {
+		t.Fatalf("spans = %v; want one span named op", spans)
+	}
+
+	want := map[attribute.Key]string{
+		"service.name":           "app",
+		"service.version":        "v1.2.3",
+		"deployment.environment": "test",
+	}
+	for key, value := range want {
+		got, ok := spans[0].Resource.Set().Value(key)
+		if !ok || got.AsString() != value {
+			t.Errorf("resource %s = %q; want %q", key, got.AsString(), value)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := p.MetricReader().Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect error: %v", err)
+	}
+	if len(rm.ScopeMetrics) != 1 || len(rm.ScopeMetrics[0].Metrics) != 1 {
+		t.Fatalf("metrics = %+v; want the requests counter", rm.ScopeMetrics)
+	}
+	sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 3 {
+		t.Fatalf("requests = %+v; want 3", rm.ScopeMetrics[0].Metrics[0].Data)
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	// nothing is recorded after shutdown
+	_, span = otel.Tracer("test").Start(context.Background(), "late")
+	span.End()
+	if spans := p.SpanExporter().GetSpans(); len(spans) != 0 {
+		t.Fatalf("spans after Stop = %v; want none", spans)
+	}
+}
+
+func TestNew_SamplingRatio(t *testing.T) {
+	p, err := mxotel.New(t.Context(),
+		mxotel.Config{Enabled: true, Exporter: mxotel.ExporterInMemory, SamplingRatio: 0},
+		mxotel.WithServiceName("app"),
+	)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Stop(context.Background()) })
+
+	_, span := p.TracerProvider().Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	if spans := p.SpanExporter().GetSpans(); len(spans) != 0 {
+		t.Fatalf("spans = %v; want none with sampling ratio 0", spans)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  mxotel.Config
+		opts []mxotel.Option
+	}{
+		{name: "disabled", cfg: mxotel.Config{Exporter: mxotel.ExporterInMemory}, opts: []mxotel.Option{mxotel.WithServiceName("app")}},
+		{name: "no service name", cfg: mxotel.Config{Enabled: true, Exporter: mxotel.ExporterInMemory}},
+		{name: "unknown exporter", cfg: mxotel.Config{Enabled: true, Exporter: "zipkin"}, opts: []mxotel.Option{mxotel.WithServiceName("app")}},
+		{name: "sampling ratio above 1", cfg: mxotel.Config{Enabled: true, Exporter: mxotel.ExporterInMemory, SamplingRatio: 1.5}, opts: []mxotel.Option{mxotel.WithServiceName("app")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mxotel.New(t.Context(), tt.cfg, tt.opts...); err == nil {
+				t.Fatal("New error = nil; want an error")
+			}
+		})
+	}
+}
+
+func TestProvider_Service(t *testing.T) {
+	p, err := mxotel.New(t.Context(),
+		mxotel.Config{Enabled: true, Exporter: mxotel.ExporterInMemory, SamplingRatio: 1},
+		mxotel.WithServiceName("app"),
+	)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	if p.Name() != "otel" {
+		t.Errorf("Name = %q; want otel", p.Name())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Start(ctx) }()
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+}
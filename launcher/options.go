@@ -2,6 +2,7 @@ package launcher
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"github.com/tkcrm/mx/launcher/ops"
@@ -16,22 +17,58 @@ type Options struct {
 	Name    string
 	Version string
 
-	// Before and After funcs
+	// Before and After hooks
 	BeforeStart []func() error
 	BeforeStop  []func() error
 	AfterStart  []func() error
 	AfterStop   []func() error
 
+	// Named hooks, run after the funcs of the same phase
+	BeforeStartHooks []Hook
+	BeforeStopHooks  []Hook
+	AfterStartHooks  []Hook
+	AfterStopHooks   []Hook
+
+	// StateChangeHandlers receive the state transitions of all services.
+	StateChangeHandlers []func(LifecycleEvent)
+
 	AppStartStopLog bool
 
 	RunnerServicesSequence RunnerServicesSequence
 
 	Signal bool
 
-	// GlobalShutdownTimeout limits the total time allowed for all services to stop.
+	// ShutdownSignals start a graceful shutdown. Default ShutdownSiganl().
+	ShutdownSignals []os.Signal
+	// ReloadSignals reload every running service implementing
+	// mxtypes.Reloader or set up with WithReload.
+	ReloadSignals []os.Signal
+	// SignalHandlers are called on the signals they are registered for.
+	SignalHandlers map[os.Signal][]func(os.Signal)
+
+	// GlobalShutdownTimeout limits the total time allowed for all services to stop,
+	// the drain included.
 	// Zero means no global timeout (each service uses its own ShutdownTimeout).
 	GlobalShutdownTimeout time.Duration
 
+	// ExitFunc is called with exit code 1 when the shutdown is forced by a
+	// second shutdown signal or by GlobalShutdownTimeout. Default os.Exit. If
+	// it returns, Run returns a *ForcedShutdownError.
+	ExitFunc func(code int)
+
+	// DrainPolicy configures the drain phase between a shutdown signal and
+	// the stop of the services.
+	DrainPolicy DrainPolicy
+
+	// PanicPolicy decides whether panics in service Start, Stop and Reload
+	// funcs, in hooks and in signal handlers are recovered as errors or crash
+	// the process. Default PanicPolicyRecover.
+	PanicPolicy PanicPolicy
+
+	// RestartBudget limits the restarts by RestartPolicy across all services.
+	// Disabled by default.
+	RestartBudget RestartBudget
+
 	Context context.Context //nolint:containedctx
 
 	OpsConfig ops.Config
@@ -48,7 +85,9 @@ func newOptions(opts ...Option) Options {
 
 		RunnerServicesSequence: RunnerServicesSequenceNone,
 
-		Signal: true,
+		Signal:          true,
+		ShutdownSignals: ShutdownSiganl(),
+		ExitFunc:        os.Exit,
 
 		Context: context.Background(),
 	}
@@ -82,12 +121,67 @@ func WithSignal(b bool) Option {
 	return func(o *Options) { o.Signal = b }
 }
 
+// WithShutdownSignals replaces the signals starting a graceful shutdown,
+// SIGTERM, SIGINT and SIGQUIT by default. A second shutdown signal forces exit.
+func WithShutdownSignals(sigs ...os.Signal) Option {
+	return func(o *Options) { o.ShutdownSignals = sigs }
+}
+
+// WithReloadSignals sets the signals, e.g. SIGHUP, on which the launcher
+// reloads every running service implementing mxtypes.Reloader. Reload errors
+// are logged and reported as events with reason EventReasonReload.
+func WithReloadSignals(sigs ...os.Signal) Option {
+	return func(o *Options) { o.ReloadSignals = sigs }
+}
+
+// WithSignalHandler calls fn whenever sig arrives, e.g. SIGUSR1 to dump the
+// goroutines. Handlers run one signal at a time, outside of the launcher main
+// loop. Handlers of shutdown signals are never called.
+func WithSignalHandler(sig os.Signal, fn func(os.Signal)) Option {
+	return func(o *Options) {
+		if o.SignalHandlers == nil {
+			o.SignalHandlers = make(map[os.Signal][]func(os.Signal))
+		}
+		o.SignalHandlers[sig] = append(o.SignalHandlers[sig], fn)
+	}
+}
+
 // WithGlobalShutdownTimeout sets an upper bound on the total graceful shutdown duration.
 // If all services do not stop within this duration, the launcher exits immediately.
 func WithGlobalShutdownTimeout(d time.Duration) Option {
 	return func(o *Options) { o.GlobalShutdownTimeout = d }
 }
 
+// WithDrainPolicy sets the drain phase run on a shutdown signal: the ops
+// readiness probe reports "draining" while the launcher waits as configured
+// before it runs the before stop hooks and stops the services.
+func WithDrainPolicy(p DrainPolicy) Option {
+	return func(o *Options) { o.DrainPolicy = p }
+}
+
+// WithExitFunc replaces os.Exit on a forced shutdown, e.g. to flush telemetry
+// first or to keep a test binary alive. The services still stopping and their
+// goroutine stacks are logged before fn is called. If fn returns, Run returns
+// a *ForcedShutdownError wrapping ErrForcedShutdown.
+func WithExitFunc(fn func(code int)) Option {
+	return func(o *Options) { o.ExitFunc = fn }
+}
+
+// WithPanicPolicy sets whether panics in service Start, Stop and Reload
+// funcs, in hooks and in signal handlers are recovered, e.g. failing the
+// service with a *PanicError that RestartPolicy can restart it on, or
+// re-raised to crash the process.
+func WithPanicPolicy(p PanicPolicy) Option {
+	return func(o *Options) { o.PanicPolicy = p }
+}
+
+// WithRestartBudget limits the restarts by RestartPolicy across all services,
+// e.g. to at most 10 within 5 minutes. Once a restart exceeds the budget, the
+// launcher shuts down or fails the liveness probe, as set by its Action.
+func WithRestartBudget(b RestartBudget) Option {
+	return func(o *Options) { o.RestartBudget = b }
+}
+
 func WithLogger(l logger.ExtendedLogger) Option {
 	return func(o *Options) { o.logger = l }
 }
@@ -129,3 +223,39 @@ func WithAfterStop(fn func() error) Option {
 		o.AfterStop = append(o.AfterStop, fn)
 	}
 }
+
+// WithBeforeStartHook runs the named hook h before the services start.
+func WithBeforeStartHook(h Hook) Option {
+	return func(o *Options) {
+		o.BeforeStartHooks = append(o.BeforeStartHooks, h)
+	}
+}
+
+// WithBeforeStopHook runs the named hook h before the services stop.
+func WithBeforeStopHook(h Hook) Option {
+	return func(o *Options) {
+		o.BeforeStopHooks = append(o.BeforeStopHooks, h)
+	}
+}
+
+// WithAfterStartHook runs the named hook h after the services start.
+func WithAfterStartHook(h Hook) Option {
+	return func(o *Options) {
+		o.AfterStartHooks = append(o.AfterStartHooks, h)
+	}
+}
+
+// WithAfterStopHook runs the named hook h after the services stop.
+func WithAfterStopHook(h Hook) Option {
+	return func(o *Options) {
+		o.AfterStopHooks = append(o.AfterStopHooks, h)
+	}
+}
+
+// WithStateChangeHandler subscribes fn to the state transitions of all
+// services. See ILauncher.OnStateChange.
+func WithStateChangeHandler(fn func(LifecycleEvent)) Option {
+	return func(o *Options) {
+		o.StateChangeHandlers = append(o.StateChangeHandlers, fn)
+	}
+}
@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/tkcrm/mx/logger"
+)
+
+// PanicPolicy decides what happens to a panic in a service Start, Stop or
+// Reload func, in a hook or in a signal handler.
+type PanicPolicy int
+
+const (
+	// PanicPolicyRecover turns the panic into a *PanicError, so the service
+	// fails, or is restarted by its RestartPolicy, instead of crashing the
+	// process. It is the default.
+	PanicPolicyRecover PanicPolicy = iota
+	// PanicPolicyRepanic lets the panic crash the process.
+	PanicPolicyRepanic
+)
+
+// PanicError is a recovered panic. It is wrapped in the error of the phase
+// the panic happened in, e.g. *StartError or *HookError.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// call calls fn. Unless the policy re-raises panics, a panic in fn is logged
+// with its stack and returned as a *PanicError; what names fn in the log.
+func (p PanicPolicy) call(log logger.Logger, what string, fn func() error) (err error) {
+	if p == PanicPolicyRepanic {
+		return fn()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			panicErr := &PanicError{Value: v, Stack: debug.Stack()}
+			log.Errorf("%s panicked: %v\n%s", what, v, panicErr.Stack)
+			err = panicErr
+		}
+	}()
+
+	return fn()
+}
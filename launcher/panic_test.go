@@ -0,0 +1,151 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+func TestLauncher_Panic_Start(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		svc := launcher.NewService(
+			launcher.WithServiceName("api"),
+			launcher.WithStart(func(context.Context) error { panic("nil map") }),
+			launcher.WithStop(noopStop),
+		)
+		ln.ServicesRunner().Register(svc)
+
+		err := ln.Run()
+
+		var startErr *launcher.StartError
+		if !errors.As(err, &startErr) || startErr.Service != "api" {
+			t.Fatalf("Run error = %v; want *StartError of api", err)
+		}
+		var panicErr *launcher.PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("Run error = %v; want *PanicError", err)
+		}
+		if panicErr.Value != "nil map" || len(panicErr.Stack) == 0 {
+			t.Errorf("PanicError = %v with %d bytes of stack; want value nil map and a stack", panicErr.Value, len(panicErr.Stack))
+		}
+		if svc.State() != launcher.ServiceStateFailed {
+			t.Errorf("state = %s; want failed", svc.State())
+		}
+	})
+}
+
+func TestLauncher_Panic_Restart(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+
+		attempts := 0
+		svc := launcher.NewService(
+			launcher.WithServiceName("worker"),
+			launcher.WithStart(func(ctx context.Context) error {
+				attempts++
+				if attempts == 1 {
+					panic(errors.New("index out of range"))
+				}
+				<-ctx.Done()
+				return nil
+			}),
+			launcher.WithStop(noopStop),
+			launcher.WithRestartPolicy(launcher.RestartPolicy{
+				Mode:  launcher.RestartOnFailure,
+				Delay: time.Second,
+			}),
+		)
+		ln.ServicesRunner().Register(svc)
+
+		var panicked bool
+		ln.OnStateChange(func(e launcher.LifecycleEvent) {
+			var panicErr *launcher.PanicError
+			if e.Reason == launcher.EventReasonRestartPolicy && errors.As(e.Err, &panicErr) {
+				panicked = true
+			}
+		})
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+
+		time.Sleep(2 * time.Second)
+		synctest.Wait()
+
+		if attempts != 2 || svc.Restarts() != 1 {
+			t.Errorf("attempts = %d, restarts = %d; want the panicking service restarted once", attempts, svc.Restarts())
+		}
+		if !panicked {
+			t.Error("no restart event with the *PanicError")
+		}
+		if svc.State() != launcher.ServiceStateRunning {
+			t.Errorf("state = %s; want running", svc.State())
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Errorf("Run error = %v; want nil", err)
+		}
+	})
+}
+
+func TestLauncher_Panic_StopAndHooks(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher(
+			launcher.WithLogger(quietExtended()),
+			launcher.WithAfterStopHook(launcher.Hook{
+				Name:    "flush",
+				Fn:      func(context.Context) error { panic("flush") },
+				Timeout: time.Second,
+			}),
+		)
+		svc := launcher.NewService(
+			launcher.WithServiceName("db"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(func(context.Context) error { panic("close") }),
+		)
+		ln.ServicesRunner().Register(svc)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- ln.Run() }()
+		synctest.Wait()
+		ln.Stop()
+
+		err := <-errCh
+
+		var stopErr *launcher.StopError
+		var panicErr *launcher.PanicError
+		if !errors.As(err, &stopErr) || !errors.As(stopErr, &panicErr) || panicErr.Value != "close" {
+			t.Errorf("Run error = %v; want *StopError of the panic", err)
+		}
+		if svc.State() != launcher.ServiceStateFailed {
+			t.Errorf("state = %s; want failed", svc.State())
+		}
+
+		var hookErr *launcher.HookError
+		if !errors.As(err, &hookErr) || !errors.As(hookErr, &panicErr) || panicErr.Value != "flush" {
+			t.Errorf("Run error = %v; want *HookError of the panic", err)
+		}
+	})
+}
+
+func TestLauncher_Panic_Repanic(t *testing.T) {
+	ln := newTestLauncher(
+		launcher.WithLogger(quietExtended()),
+		launcher.WithPanicPolicy(launcher.PanicPolicyRepanic),
+		launcher.WithBeforeStart(func() error { panic("boom") }),
+	)
+
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("recovered %v; want the hook panic re-raised", v)
+		}
+	}()
+
+	_ = ln.Run()
+	t.Error("Run returned; want the hook panic re-raised")
+}
@@ -1,6 +1,13 @@
 package launcher
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnhealthy is the failure of a service stopped because its health checks
+// kept failing (see RestartPolicy.UnhealthyThreshold).
+var ErrUnhealthy = errors.New("service unhealthy")
 
 // RestartMode defines when a service should be restarted after exit.
 type RestartMode int
@@ -22,33 +29,87 @@ type RestartPolicy struct {
 	// MaxRetries is the maximum number of restart attempts. 0 means unlimited.
 	MaxRetries int
 
-	// Delay is the initial wait before the first restart attempt.
+	// Delay is the initial wait before the first restart attempt. Default 1s.
+	// Used by the default backoff only.
 	Delay time.Duration
 
-	// MaxDelay caps the exponential backoff. Zero defaults to Delay (no backoff growth).
+	// MaxDelay caps the exponential growth of the default backoff. Zero means
+	// no cap.
 	MaxDelay time.Duration
+
+	// Backoff computes the delay before each restart. Default exponential
+	// backoff from Delay, capped at MaxDelay, without jitter.
+	Backoff Backoff
+
+	// ResetAfter resets the restart attempts, and with them MaxRetries and
+	// the backoff, once the service ran for at least this long before it
+	// exited. Zero means the attempts are never reset.
+	ResetAfter time.Duration
+
+	// Breaker holds the restarts for a cooldown once the service keeps
+	// failing. Disabled by default.
+	Breaker Breaker
+
+	// UnhealthyThreshold stops a running service after this many consecutive
+	// failed polls of its HealthChecker by the ops health checker. The stop
+	// is handled as a failure: with RestartOnFailure or RestartAlways the
+	// service is restarted, otherwise it fails. Zero disables it.
+	UnhealthyThreshold int
 }
 
-// nextDelay returns the backoff delay for attempt n (0-indexed) and whether
-// another retry is allowed.
-func (p RestartPolicy) nextDelay(attempt int) (time.Duration, bool) {
+// Breaker is a circuit breaker on the restarts of a service. Once the service
+// failed Failures times within Window, the breaker opens and the next restart
+// waits for Cooldown instead of the backoff delay; the failures are counted
+// anew from then on.
+type Breaker struct {
+	// Failures opens the breaker. Zero disables it.
+	Failures int
+
+	// Window is the sliding window the failures are counted in. Zero counts
+	// all failures since the breaker last opened.
+	Window time.Duration
+
+	// Cooldown is how long the open breaker holds the restart.
+	Cooldown time.Duration
+}
+
+// record adds a failure at now to the earlier failures and reports whether the
+// breaker opens, in which case the failures are cleared.
+func (b Breaker) record(failures []time.Time, now time.Time) ([]time.Time, bool) {
+	if b.Failures <= 0 {
+		return nil, false
+	}
+
+	if b.Window > 0 {
+		i := 0
+		for i < len(failures) && now.Sub(failures[i]) >= b.Window {
+			i++
+		}
+		failures = failures[i:]
+	}
+
+	failures = append(failures, now)
+	if len(failures) < b.Failures {
+		return failures, false
+	}
+	return nil, true
+}
+
+// nextDelay returns the backoff delay for attempt n (0-indexed) after a delay
+// of prev, and whether another retry is allowed.
+func (p RestartPolicy) nextDelay(attempt int, prev time.Duration) (time.Duration, bool) {
 	if p.MaxRetries > 0 && attempt >= p.MaxRetries {
 		return 0, false
 	}
 
+	if p.Backoff != nil {
+		return max(p.Backoff.Next(attempt, prev), 0), true
+	}
+
 	delay := p.Delay
 	if delay <= 0 {
 		delay = time.Second
 	}
 
-	// exponential backoff: delay * 2^attempt
-	for range attempt {
-		delay *= 2
-		if p.MaxDelay > 0 && delay > p.MaxDelay {
-			delay = p.MaxDelay
-			break
-		}
-	}
-
-	return delay, true
+	return exponential(delay, p.MaxDelay, attempt), true
 }
@@ -2,7 +2,9 @@ package launcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -28,20 +30,75 @@ type Service struct {
 	// state holds the current ServiceState as an int32 so it can be read
 	// (State) from ops probe handlers concurrently with the service goroutine
 	// updating it, without a data race.
-	state   atomic.Int32
-	readyCh chan struct{}
+	state atomic.Int32
+
+	// ready reports whether the current run became ready.
+	ready atomic.Bool
+	// restarts counts restarts, both by RestartPolicy and on demand.
+	restarts atomic.Int32
+	// attempt is the start attempt of the current run, starting at 1.
+	attempt atomic.Int32
+	// reason is attached to transitions while an externally initiated
+	// operation, such as an on-demand restart, is in progress.
+	reason atomic.Pointer[string]
+	// onStateChange receives every state transition; set on registration.
+	onStateChange func(LifecycleEvent)
+	// onHook receives every run of the service hooks; set on registration.
+	onHook func(hookRun)
+	// panics decides whether a panic in the service funcs and hooks fails
+	// the service or crashes the process; set on registration.
+	panics PanicPolicy
+	// budget limits the restarts by RestartPolicy across the services; set on
+	// registration, nil without a budget.
+	budget *restartBudget
+	// lastErr holds the last error the service failed with.
+	lastErr atomic.Pointer[error]
+	// since holds the time of the last state change in Unix nanoseconds.
+	since atomic.Int64
+
+	// healthFailures counts the consecutive failed health checks and
+	// unhealthy receives the error stopping the service once they reach
+	// RestartPolicy.UnhealthyThreshold.
+	healthFailures atomic.Int32
+	unhealthy      chan error
+
+	// internal marks services registered by the launcher itself (ops servers),
+	// which are not reported by the ops probes: before the probes followed the
+	// services runner, they got the services registered ahead of the ops
+	// servers only.
+	internal bool
+
+	// runCancel cancels the context of the current Start call and runDone is
+	// closed when that call returns, so a single service can be halted without
+	// cancelling the launcher context.
+	// readyCh is guarded by runMu too, as a restart replaces it.
+	// removed is set by Remove, so a start still waiting for its
+	// dependencies does not run.
+	runMu     sync.Mutex
+	runCancel context.CancelFunc
+	runDone   chan struct{}
+	readyCh   chan struct{}
+	removed   bool
+
+	// ctlMu serialises on-demand control operations (restart, removal).
+	ctlMu sync.Mutex
 }
 
 // NewService creates a new Service.
 func NewService(opts ...ServiceOption) *Service {
 	return &Service{
-		opts:    newServiceOptions(opts...),
-		readyCh: make(chan struct{}),
+		opts:      newServiceOptions(opts...),
+		readyCh:   make(chan struct{}),
+		unhealthy: make(chan error, 1),
 	}
 }
 
 // Ready returns a channel that is closed when the service transitions to Running state.
-func (s *Service) Ready() <-chan struct{} { return s.readyCh }
+func (s *Service) Ready() <-chan struct{} {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	return s.readyCh
+}
 
 func (s *Service) Name() string { return s.opts.Name }
 
@@ -49,17 +106,95 @@ func (s *Service) Name() string { return s.opts.Name }
 func (s *Service) State() ServiceState { return ServiceState(s.state.Load()) }
 
 // setState atomically updates the service's lifecycle state.
-func (s *Service) setState(v ServiceState) { s.state.Store(int32(v)) }
+func (s *Service) setState(v ServiceState) { s.transition(v, nil, "") }
+
+// transition updates the lifecycle state and reports the change, together with
+// the error and reason that caused it. Transitions to the same state are not
+// reported.
+func (s *Service) transition(to ServiceState, err error, reason string) {
+	from := ServiceState(s.state.Swap(int32(to)))
+	if from == to {
+		return
+	}
+	s.since.Store(time.Now().UnixNano())
+
+	if s.onStateChange == nil {
+		return
+	}
+
+	if reason == "" {
+		if r := s.reason.Load(); r != nil {
+			reason = *r
+		}
+	}
+
+	s.onStateChange(LifecycleEvent{
+		Service: s.Name(),
+		From:    from,
+		To:      to,
+		Attempt: int(s.attempt.Load()),
+		Err:     err,
+		Reason:  reason,
+		Time:    time.Now(),
+	})
+}
+
+// setReason attaches reason to the following transitions; empty clears it.
+func (s *Service) setReason(reason string) {
+	if reason == "" {
+		s.reason.Store(nil)
+		return
+	}
+	s.reason.Store(&reason)
+}
+
+// Restarts returns how many times the service was restarted, either by its
+// RestartPolicy or on demand.
+func (s *Service) Restarts() int { return int(s.restarts.Load()) }
+
+// Uptime returns how long the service has been running, or zero if it is
+// not running.
+func (s *Service) Uptime() time.Duration {
+	if s.State() != ServiceStateRunning {
+		return 0
+	}
+	return time.Since(time.Unix(0, s.since.Load()))
+}
+
+// LastError returns the last error the service failed with, or nil.
+func (s *Service) LastError() error {
+	if err := s.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// recordErr remembers err as the last error of the service. Nil is ignored.
+func (s *Service) recordErr(err error) {
+	if err != nil {
+		s.lastErr.Store(&err)
+	}
+}
 
 func (s *Service) Options() *ServiceOptions { return &s.opts }
 
 func (s *Service) String() string { return "mx" }
 
-func (s *Service) Start() error {
+func (s *Service) Start() (err error) {
 	// Ensure the readiness channel is always closed on return, so the launcher's
 	// startup-priority barrier never blocks on a service that failed or exited
-	// before signalling ready.
-	defer s.closeReady()
+	// before signalling ready. The error is recorded first, so it is visible to
+	// whoever waits on readiness, and done is closed last, so a halted run never
+	// touches the readiness channel of the next one.
+	var done chan struct{}
+	defer func() {
+		err = startError(s, err)
+		s.recordErr(err)
+		s.closeReady()
+		if done != nil {
+			close(done)
+		}
+	}()
 
 	if s.opts.StartFn == nil {
 		return nil
@@ -73,23 +208,41 @@ func (s *Service) Start() error {
 	if s.State() == ServiceStateStarting || s.State() == ServiceStateRunning {
 		return nil
 	}
+
+	ctx, cancel := context.WithCancel(s.opts.Context)
+	defer cancel()
+
+	// publish the run before checking for a removal, so Remove either sees
+	// the run to halt or the run sees the removal
+	s.runMu.Lock()
+	if s.removed {
+		s.runMu.Unlock()
+		return nil
+	}
+	done = make(chan struct{})
+	s.runCancel, s.runDone = cancel, done
+	s.runMu.Unlock()
+
+	s.attempt.Store(1)
+	s.ready.Store(false)
 	s.setState(ServiceStateStarting)
 
 	s.opts.Logger.Infof("starting service [%s]", s.Name())
 
-	for _, fn := range s.opts.BeforeStart {
-		if err := fn(); err != nil {
-			s.setState(ServiceStateFailed)
-			return err
-		}
+	if err := s.hooks(PhaseBeforeStart).run(ctx, phaseHooks(s.opts.BeforeStart, s.opts.BeforeStartHooks), true); err != nil {
+		s.transition(ServiceStateFailed, err, "")
+		return err
 	}
 
-	err := s.runWithRestarts()
+	err = s.runWithRestarts(ctx)
 
-	for _, fn := range s.opts.AfterStartFinished {
-		if fnErr := fn(); fnErr != nil {
-			return fnErr
-		}
+	// a job that ran to completion has nothing left to stop
+	if s.opts.OneShot && err == nil && ctx.Err() == nil {
+		s.setState(ServiceStateStopped)
+	}
+
+	if hookErr := s.hooks(PhaseAfterStartFinished).run(s.opts.Context, phaseHooks(s.opts.AfterStartFinished, s.opts.AfterStartFinishedHooks), true); hookErr != nil {
+		return hookErr
 	}
 
 	return err
@@ -107,23 +260,41 @@ func (s *Service) Start() error {
 // Readiness is gated only on the first attempt; restarts go straight back to
 // Running. AfterStart hooks fire once, right after the service first becomes
 // ready.
-func (s *Service) runWithRestarts() error {
+func (s *Service) runWithRestarts(ctx context.Context) error {
 	policy := s.opts.RestartPolicy
 	gatedReady := false
 
+	// retry counts the restarts since the last reset by ResetAfter and
+	// prevDelay is the delay before the last one, both for the backoff;
+	// failures are the failures counted by the breaker.
+	var (
+		retry     int
+		prevDelay time.Duration
+		failures  []time.Time
+	)
+
 	for attempt := 0; ; attempt++ {
+		s.resetHealth()
+
+		// cancelRun cancels the context of this attempt only, to stop an
+		// unhealthy service
+		runCtx, cancelRun := context.WithCancel(ctx)
+
 		errChan := make(chan error, 1)
 		doneChan := make(chan struct{}, 1)
-		go func() {
-			if err := s.opts.StartFn(s.opts.Context); err != nil {
+		started := time.Now()
+		go withServiceLabel(runCtx, s.Name(), func(ctx context.Context) {
+			defer cancelRun()
+			if err := s.call("start", func() error { return s.opts.StartFn(ctx) }); err != nil {
 				errChan <- err
 				return
 			}
 			doneChan <- struct{}{}
-		}()
+		})
 
 		var exitErr error
 		exitedDuringStartup := false
+		reason := EventReasonRestartPolicy
 
 		if !gatedReady {
 			gatedReady = true
@@ -147,9 +318,14 @@ func (s *Service) runWithRestarts() error {
 				case <-doneChan:
 					exitedDuringStartup = true
 				case <-timeout:
-					exitErr = fmt.Errorf("service [%s] startup timeout exceeded (%s)", s.Name(), s.opts.StartupTimeout)
+					exitErr = &StartupTimeoutError{
+						Service: s.Name(),
+						Phase:   PhaseStart,
+						Attempt: int(s.attempt.Load()),
+						Timeout: s.opts.StartupTimeout,
+					}
 					exitedDuringStartup = true
-				case <-s.opts.Context.Done():
+				case <-ctx.Done():
 					return s.awaitShutdown(errChan, doneChan)
 				}
 			}
@@ -157,6 +333,7 @@ func (s *Service) runWithRestarts() error {
 			switch {
 			case !exitedDuringStartup:
 				// Became ready.
+				s.ready.Store(true)
 				s.setState(ServiceStateRunning)
 				s.closeReady()
 				if err := s.runAfterStart(); err != nil {
@@ -180,7 +357,10 @@ func (s *Service) runWithRestarts() error {
 				exitErr = err
 			case <-doneChan:
 				// clean exit
-			case <-s.opts.Context.Done():
+			case err := <-s.unhealthy:
+				exitErr, reason = err, EventReasonUnhealthy
+				s.stopUnhealthy(err, cancelRun, errChan, doneChan)
+			case <-ctx.Done():
 				return s.awaitShutdown(errChan, doneChan)
 			}
 		}
@@ -194,40 +374,146 @@ func (s *Service) runWithRestarts() error {
 			shouldRestart = true
 		}
 
+		// a failure is reported with a reason only when the service was
+		// stopped for it
+		failReason := ""
+		if reason == EventReasonUnhealthy {
+			failReason = reason
+		}
+
 		if !shouldRestart {
 			if exitErr != nil {
-				s.setState(ServiceStateFailed)
+				s.transition(ServiceStateFailed, exitErr, failReason)
 				return exitErr
 			}
 			return nil
 		}
 
-		delay, allowed := policy.nextDelay(attempt)
+		// a service that ran long enough before exiting starts over
+		if policy.ResetAfter > 0 && time.Since(started) >= policy.ResetAfter {
+			retry, prevDelay = 0, 0
+		}
+
+		delay, allowed := policy.nextDelay(retry, prevDelay)
 		if !allowed {
-			s.setState(ServiceStateFailed)
+			s.transition(ServiceStateFailed, exitErr, failReason)
 			if exitErr != nil {
 				return fmt.Errorf("service [%s] failed after %d restart attempt(s): %w", s.Name(), attempt+1, exitErr)
 			}
 			return nil
 		}
 
+		retry, prevDelay = retry+1, delay
+
+		if exitErr != nil {
+			var open bool
+			if failures, open = policy.Breaker.record(failures, time.Now()); open {
+				delay = max(delay, policy.Breaker.Cooldown)
+				s.opts.Logger.Warnf("circuit breaker of service [%s] opened after %d failure(s), holding the restart for %s", s.Name(), policy.Breaker.Failures, delay)
+			}
+		}
+
+		if err := s.budget.take(s.Name()); err != nil {
+			if exitErr != nil {
+				err = fmt.Errorf("%w: %w", err, exitErr)
+			}
+			s.transition(ServiceStateFailed, err, failReason)
+			return err
+		}
+
+		s.restarts.Add(1)
 		if exitErr != nil {
+			s.recordErr(exitErr)
 			s.opts.Logger.Warnf("service [%s] failed (attempt %d), restarting in %s: %s", s.Name(), attempt+1, delay, exitErr)
 		} else {
 			s.opts.Logger.Infof("service [%s] exited cleanly (attempt %d), restarting in %s", s.Name(), attempt+1, delay)
 		}
 
-		s.setState(ServiceStateStarting)
+		s.attempt.Store(int32(attempt) + 2)
+		s.transition(ServiceStateStarting, exitErr, reason)
 		select {
 		case <-time.After(delay):
-		case <-s.opts.Context.Done():
+		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
+// observeHealth counts the consecutive failed health checks of the service.
+// Once they reach RestartPolicy.UnhealthyThreshold, the running service is
+// stopped and handled as failed with an error wrapping ErrUnhealthy.
+func (s *Service) observeHealth(err error) {
+	threshold := s.opts.RestartPolicy.UnhealthyThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	if err == nil {
+		s.healthFailures.Store(0)
+		return
+	}
+
+	if s.healthFailures.Add(1) < int32(threshold) || s.State() != ServiceStateRunning {
+		return
+	}
+	s.healthFailures.Store(0)
+
+	select {
+	case s.unhealthy <- fmt.Errorf("%w after %d failed health check(s): %w", ErrUnhealthy, threshold, err):
+	default:
+	}
+}
+
+// resetHealth forgets the health check failures of the previous attempt.
+func (s *Service) resetHealth() {
+	s.healthFailures.Store(0)
+	select {
+	case <-s.unhealthy:
+	default:
+	}
+}
+
+// stopUnhealthy stops the current attempt of the unhealthy service the way
+// Stop does: it runs the before stop hooks, cancels its context and calls
+// StopFn, waits for StartFn to return, each bounded by ShutdownTimeout, and
+// runs the after stop hooks. Failures are logged, as the service is handled
+// as failed anyway.
+func (s *Service) stopUnhealthy(cause error, cancelRun context.CancelFunc, errChan chan error, doneChan chan struct{}) {
+	s.opts.Logger.Warnf("service [%s] is unhealthy, stopping it: %s", s.Name(), cause)
+	s.transition(ServiceStateStopping, cause, EventReasonUnhealthy)
+
+	if err := s.hooks(PhaseBeforeStop).run(context.Background(), phaseHooks(s.opts.BeforeStop, s.opts.BeforeStopHooks), false); err != nil {
+		s.opts.Logger.Warnf("stopping unhealthy service: %s", err)
+	}
+
+	cancelRun()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+
+	if s.opts.StopFn != nil {
+		if err := s.call("stop", func() error { return s.opts.StopFn(ctx) }); err != nil {
+			s.opts.Logger.Warnf("service [%s] failed to stop: %s", s.Name(), err)
+		}
+	}
+
+	select {
+	case <-errChan:
+	case <-doneChan:
+	case <-ctx.Done():
+		s.opts.Logger.Infof("service [%s] was stopped by timeout", s.Name())
+	}
+
+	if err := s.hooks(PhaseAfterStop).run(context.Background(), phaseHooks(s.opts.AfterStop, s.opts.AfterStopHooks), false); err != nil {
+		s.opts.Logger.Warnf("stopping unhealthy service: %s", err)
+	}
+}
+
 // closeReady closes the readiness channel exactly once.
 func (s *Service) closeReady() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
 	select {
 	case <-s.readyCh:
 	default:
@@ -235,31 +521,117 @@ func (s *Service) closeReady() {
 	}
 }
 
+// resetReady replaces the closed readiness channel before the service is
+// started again.
+func (s *Service) resetReady() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.readyCh = make(chan struct{})
+}
+
+// becameReady reports whether the current run became ready, as opposed to
+// failing during startup.
+func (s *Service) becameReady() bool { return s.ready.Load() }
+
+// setRemoved marks the service removed, or registered again.
+func (s *Service) setRemoved(v bool) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.removed = v
+}
+
+// halt cancels the context of the current Start call and waits for it to
+// return, which is bounded by ShutdownTimeout. It does not call StopFn.
+func (s *Service) halt() {
+	s.runMu.Lock()
+	cancel, done := s.runCancel, s.runDone
+	s.runMu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
 // runAfterStart runs the AfterStart hooks. On the first hook error it marks the
 // service failed and returns that error. Called once, right after the service
 // first becomes ready.
 func (s *Service) runAfterStart() error {
-	for _, fn := range s.opts.AfterStart {
-		if err := fn(); err != nil {
-			s.setState(ServiceStateFailed)
-			return err
-		}
+	if err := s.hooks(PhaseAfterStart).run(s.opts.Context, phaseHooks(s.opts.AfterStart, s.opts.AfterStartHooks), true); err != nil {
+		s.transition(ServiceStateFailed, err, "")
+		return err
 	}
 	return nil
 }
 
+// hooks returns the runner of the service hooks of phase.
+func (s *Service) hooks(phase Phase) hookRunner {
+	return hookRunner{
+		logger: s.opts.Logger,
+		base: HookError{
+			Service: s.Name(),
+			Phase:   phase,
+			Attempt: int(s.attempt.Load()),
+		},
+		observe: s.onHook,
+		panics:  s.panics,
+	}
+}
+
+// call calls fn, a func of the service named by what, handling a panic in it
+// according to the panic policy.
+func (s *Service) call(what string, fn func() error) error {
+	return s.panics.call(s.opts.Logger, fmt.Sprintf("%s of service [%s]", what, s.Name()), fn)
+}
+
 // awaitShutdown waits for the StartFn goroutine to finish after the context was
-// cancelled, bounded by ShutdownTimeout, and always returns nil.
+// cancelled, bounded by ShutdownTimeout. A service halted on its own returns
+// nil. While the launcher shuts down, the error the service exits with is
+// returned, unless it only reports the cancellation, so a failure at the same
+// moment as the one shutting the launcher down is not lost.
 func (s *Service) awaitShutdown(errChan chan error, doneChan chan struct{}) error {
 	select {
 	case <-time.After(s.opts.ShutdownTimeout):
 		s.opts.Logger.Infof("service [%s] was stopped by timeout", s.Name())
 	case <-doneChan:
-	case <-errChan:
+	case err := <-errChan:
+		if s.opts.Context.Err() == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		s.transition(ServiceStateFailed, err, "")
+		return err
 	}
 	return nil
 }
 
+// Reload calls the reload function of the service, if any, and reports the
+// outcome as an event with reason EventReasonReload. A panic in it is handled
+// by the PanicPolicy.
+func (s *Service) Reload(ctx context.Context) error {
+	if s.opts.ReloadFn == nil {
+		return nil
+	}
+
+	err := s.call("reload", func() error { return s.opts.ReloadFn(ctx) })
+
+	if s.onStateChange != nil {
+		state := s.State()
+		s.onStateChange(LifecycleEvent{
+			Service: s.Name(),
+			From:    state,
+			To:      state,
+			Attempt: int(s.attempt.Load()),
+			Err:     err,
+			Reason:  EventReasonReload,
+			Time:    time.Now(),
+		})
+	}
+
+	return err
+}
+
 func (s *Service) Stop() error {
 	if s.opts.StopFn == nil {
 		return nil
@@ -270,13 +642,7 @@ func (s *Service) Stop() error {
 	}
 	s.setState(ServiceStateStopping)
 
-	var stopErr error
-
-	for _, fn := range s.opts.BeforeStop {
-		if err := fn(); err != nil {
-			stopErr = err
-		}
-	}
+	stopErr := s.hooks(PhaseBeforeStop).run(context.Background(), phaseHooks(s.opts.BeforeStop, s.opts.BeforeStopHooks), false)
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
 	defer cancel()
@@ -285,30 +651,33 @@ func (s *Service) Stop() error {
 
 	errChan := make(chan error, 1)
 	doneChan := make(chan struct{}, 1)
-	go func() {
-		if err := s.opts.StopFn(ctx); err != nil {
+	go withServiceLabel(ctx, s.Name(), func(ctx context.Context) {
+		if err := s.call("stop", func() error { return s.opts.StopFn(ctx) }); err != nil {
 			errChan <- err
 			return
 		}
 		doneChan <- struct{}{}
-	}()
+	})
 
 	select {
 	case <-doneChan:
 		s.setState(ServiceStateStopped)
 		s.opts.Logger.Infof("service [%s] was stopped", s.Name())
 	case err := <-errChan:
-		s.setState(ServiceStateFailed)
-		return err
+		s.transition(ServiceStateFailed, err, "")
+		return &StopError{
+			Service: s.Name(),
+			Phase:   PhaseStop,
+			Attempt: int(s.attempt.Load()),
+			Err:     err,
+		}
 	case <-ctx.Done():
 		s.setState(ServiceStateStopped)
 		s.opts.Logger.Infof("failed to stop service [%s]. stop by timeout", s.Name())
 	}
 
-	for _, fn := range s.opts.AfterStop {
-		if err := fn(); err != nil {
-			stopErr = err
-		}
+	if err := s.hooks(PhaseAfterStop).run(context.Background(), phaseHooks(s.opts.AfterStop, s.opts.AfterStopHooks), false); err != nil {
+		stopErr = errors.Join(stopErr, err)
 	}
 
 	return stopErr
@@ -19,16 +19,34 @@ type ServiceOptions struct {
 	Enabled       bool
 	HealthChecker mxtypes.HealthChecker
 
+	// HealthCheckCritical reports whether a failing health check of the
+	// service makes the app not ready; otherwise it only degrades readiness.
+	// Default true.
+	HealthCheckCritical bool
+	// HealthCheckTags group the health check for the ?tag= filter of the ops
+	// probes.
+	HealthCheckTags []string
+
 	StartFn func(ctx context.Context) error
 	StopFn  func(ctx context.Context) error
+	// ReloadFn, when non-nil, is called on a reload signal while the service
+	// is running.
+	ReloadFn func(ctx context.Context) error
 
-	// Before and After funcs
+	// Before and After hooks
 	BeforeStart        []func() error
 	BeforeStop         []func() error
 	AfterStart         []func() error
 	AfterStartFinished []func() error
 	AfterStop          []func() error
 
+	// Named hooks, run after the funcs of the same phase
+	BeforeStartHooks        []Hook
+	BeforeStopHooks         []Hook
+	AfterStartHooks         []Hook
+	AfterStartFinishedHooks []Hook
+	AfterStopHooks          []Hook
+
 	Context context.Context //nolint:containedctx
 
 	// ShutdownTimeout is the maximum time to wait for Stop to complete. Default 10 seconds.
@@ -41,6 +59,11 @@ type ServiceOptions struct {
 	// considered ready as soon as its Start goroutine is launched.
 	Readiness func() <-chan struct{}
 
+	// InFlight, when non-nil, returns the number of requests the service is
+	// handling. A drain with DrainPolicy.WaitInFlight waits for it to reach
+	// zero before the services are stopped.
+	InFlight func() int64
+
 	// StartupTimeout is the maximum time to wait for a service that reports
 	// readiness (see Readiness) to become ready. A service that does not report
 	// ready within this duration is marked failed; a service that reports ready
@@ -58,6 +81,29 @@ type ServiceOptions struct {
 	// All services in a group must reach Running state before the next group starts.
 	// Priority 0 (default): start concurrently after all prioritized groups are ready.
 	StartupPriority int
+
+	// OneShot marks a job that runs to completion: once it returns, the
+	// launcher shuts down and Run returns its error as a *JobError.
+	OneShot bool
+
+	// StopPriority, when non-nil, replaces StartupPriority for the
+	// RunnerServicesSequenceReversePriority stop sequence.
+	StopPriority *int
+
+	// DependsOn lists the names of services that must be ready before this
+	// service starts. A service with dependencies ignores StartupPriority and
+	// starts as soon as all of its dependencies are ready; on shutdown it is
+	// stopped before any of them. Unknown names and cycles make Run fail
+	// before any service starts.
+	DependsOn []string
+}
+
+// stopPriority returns the priority the service is stopped with.
+func (s *ServiceOptions) stopPriority() int {
+	if s.StopPriority != nil {
+		return *s.StopPriority
+	}
+	return s.StartupPriority
 }
 
 func (s *ServiceOptions) Validate() error {
@@ -91,6 +137,8 @@ func newServiceOptions(opts ...ServiceOption) ServiceOptions {
 		Name:    defaultServiceName,
 		Enabled: true,
 
+		HealthCheckCritical: true,
+
 		BeforeStart:        make([]func() error, 0),
 		BeforeStop:         make([]func() error, 0),
 		AfterStart:         make([]func() error, 0),
@@ -123,6 +171,20 @@ func WithServiceLogger(l logger.Logger) ServiceOption {
 	return func(o *ServiceOptions) { o.Logger = l }
 }
 
+// WithHealthCheckCritical sets whether a failing health check of the service
+// makes the app not ready (true, the default) or only degrades readiness.
+func WithHealthCheckCritical(v bool) ServiceOption {
+	return func(o *ServiceOptions) { o.HealthCheckCritical = v }
+}
+
+// WithHealthCheckTags tags the health check of the service, e.g. "db", "cache"
+// or "external", so ops probes can evaluate a subset of checks with ?tag=.
+func WithHealthCheckTags(tags ...string) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.HealthCheckTags = append(o.HealthCheckTags, tags...)
+	}
+}
+
 // WithStart sets the start function of the service.
 func WithStart(fn func(context.Context) error) ServiceOption {
 	return func(o *ServiceOptions) { o.StartFn = fn }
@@ -133,6 +195,12 @@ func WithStop(fn func(context.Context) error) ServiceOption {
 	return func(o *ServiceOptions) { o.StopFn = fn }
 }
 
+// WithReload sets the reload function of the service, called on a reload
+// signal. Services wrapped with WithService get it from mxtypes.Reloader.
+func WithReload(fn func(context.Context) error) ServiceOption {
+	return func(o *ServiceOptions) { o.ReloadFn = fn }
+}
+
 // WithEnabled sets the enabled state of the service.
 func WithEnabled(v bool) ServiceOption {
 	return func(o *ServiceOptions) { o.Enabled = v }
@@ -163,6 +231,15 @@ func WithReadiness(ch <-chan struct{}) ServiceOption {
 	}
 }
 
+// WithInFlight sets the source of the number of requests the service is
+// handling, waited for by a drain with DrainPolicy.WaitInFlight. Services
+// wrapped with WithService get it from mxtypes.InFlightReporter.
+func WithInFlight(fn func() int64) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.InFlight = fn
+	}
+}
+
 // WithRestartPolicy configures automatic restart behaviour after an unexpected exit.
 func WithRestartPolicy(p RestartPolicy) ServiceOption {
 	return func(o *ServiceOptions) { o.RestartPolicy = p }
@@ -176,7 +253,31 @@ func WithStartupPriority(p int) ServiceOption {
 	return func(o *ServiceOptions) { o.StartupPriority = p }
 }
 
+// WithServiceOneShot marks the service as a job running to completion. Once
+// all one-shot services returned, or one of them failed, the launcher shuts
+// everything down in order and Run returns the job error.
+func WithServiceOneShot() ServiceOption {
+	return func(o *ServiceOptions) { o.OneShot = true }
+}
+
+// WithStopPriority sets the priority the service is stopped with by
+// RunnerServicesSequenceReversePriority, in place of its startup priority.
+// Priority 0 stops first, then higher priorities before lower ones.
+func WithStopPriority(p int) ServiceOption {
+	return func(o *ServiceOptions) { o.StopPriority = &p }
+}
+
+// WithDependsOn declares services, by name, that must be ready before this
+// service starts and that must outlive it on shutdown. It replaces startup
+// priority for this service.
+func WithDependsOn(names ...string) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.DependsOn = append(o.DependsOn, names...)
+	}
+}
+
 // WithService wraps any value that implements Name/Start/Stop/Enabled/HealthChecker.
+// Options passed after it override what it takes from the value.
 func WithService(svc any) ServiceOption {
 	return func(o *ServiceOptions) {
 		if impl, ok := svc.(interface{ Name() string }); ok {
@@ -199,9 +300,22 @@ func WithService(svc any) ServiceOption {
 			o.HealthChecker = impl
 		}
 
+		if impl, ok := svc.(mxtypes.HealthCheckClassifier); ok {
+			o.HealthCheckCritical = impl.HealthCheckCritical()
+			o.HealthCheckTags = impl.HealthCheckTags()
+		}
+
 		if impl, ok := svc.(mxtypes.ReadinessReporter); ok {
 			o.Readiness = impl.Ready
 		}
+
+		if impl, ok := svc.(mxtypes.Reloader); ok {
+			o.ReloadFn = impl.Reload
+		}
+
+		if impl, ok := svc.(mxtypes.InFlightReporter); ok {
+			o.InFlight = impl.InFlight
+		}
 	}
 }
 
@@ -239,3 +353,38 @@ func WithServiceAfterStop(fn func() error) ServiceOption {
 		o.AfterStop = append(o.AfterStop, fn)
 	}
 }
+
+// WithServiceBeforeStartHook runs the named hook h before service starts.
+func WithServiceBeforeStartHook(h Hook) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.BeforeStartHooks = append(o.BeforeStartHooks, h)
+	}
+}
+
+// WithServiceBeforeStopHook runs the named hook h before service stops.
+func WithServiceBeforeStopHook(h Hook) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.BeforeStopHooks = append(o.BeforeStopHooks, h)
+	}
+}
+
+// WithServiceAfterStartHook runs the named hook h after service starts.
+func WithServiceAfterStartHook(h Hook) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.AfterStartHooks = append(o.AfterStartHooks, h)
+	}
+}
+
+// WithServiceAfterStartFinishedHook runs the named hook h after the service Start func finishes.
+func WithServiceAfterStartFinishedHook(h Hook) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.AfterStartFinishedHooks = append(o.AfterStartFinishedHooks, h)
+	}
+}
+
+// WithServiceAfterStopHook runs the named hook h after service stops.
+func WithServiceAfterStopHook(h Hook) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.AfterStopHooks = append(o.AfterStopHooks, h)
+	}
+}
@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next activation time after t, or the zero time if
+// there is none.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// interval activates at a fixed interval.
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time { return t.Add(time.Duration(i)) }
+
+// Every returns a schedule activating every d, counted from the end of the
+// previous wait. It panics if d is not positive.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("scheduler: non-positive interval for Every")
+	}
+	return interval(d)
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record an unrestricted day of month or week: when
+	// both are restricted, a day matching either one is activated.
+	domStar, dowStar bool
+}
+
+// cronField describes the range and names of a cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors are the predefined schedules.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron parses a standard five field cron expression: minute, hour, day of
+// month, month and day of week. Fields accept *, values, ranges (1-5), lists
+// (1,15), steps (*/10, 0-30/5), and month and day names (JAN, MON). Sunday is
+// 0 or 7. The descriptors @yearly, @monthly, @weekly, @daily, @hourly and
+// "@every <duration>" are supported as well. The schedule is evaluated in the
+// location of the time passed to Next.
+func Cron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || dur <= 0 {
+			return nil, fmt.Errorf("invalid cron expression %q: bad interval", expr)
+		}
+		return interval(dur), nil
+	}
+	if std, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = std
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: want 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &cronSchedule{
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}
+
+	var err error
+	for _, f := range []struct {
+		dst   *uint64
+		expr  string
+		field cronField
+	}{
+		{&s.minute, fields[0], minuteField},
+		{&s.hour, fields[1], hourField},
+		{&s.dom, fields[2], domField},
+		{&s.month, fields[3], monthField},
+		{&s.dow, fields[4], dowField},
+	} {
+		if *f.dst, err = parseField(f.expr, f.field); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+
+	// 7 is an alias for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+// MustCron is like Cron but panics if the expression cannot be parsed.
+func MustCron(expr string) Schedule {
+	s, err := Cron(expr)
+	if err != nil {
+		panic("scheduler: " + err.Error())
+	}
+	return s
+}
+
+// parseField parses a comma separated list of ranges into a bit set.
+func parseField(expr string, f cronField) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(expr, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step %q in %s field", stepStr, f.name)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			loStr, hiStr, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiStr); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("bad range %q in %s field", rng, f.name)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// a step after a single value runs to the end of the range
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a single value or name of the field.
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("bad value %q in %s field, want %d-%d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first matching minute after t. It gives up, returning the
+// zero time, if there is none within five years, as for February 30.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
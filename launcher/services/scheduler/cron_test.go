@@ -0,0 +1,69 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tkcrm/mx/launcher/services/scheduler"
+)
+
+func TestCron_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, time.January, 14, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, 1, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 1, 14, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * MON", time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 mar *", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// a restricted day of month or week matches either
+		{"0 0 20 * SAT", time.Date(2026, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+		// there is no February 30
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		s, err := scheduler.Cron(tt.expr)
+		if err != nil {
+			t.Errorf("Cron(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Cron(%q).Next = %s; want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every -1s",
+		"@every often",
+	} {
+		if _, err := scheduler.Cron(expr); err == nil {
+			t.Errorf("Cron(%q) error = nil; want error", expr)
+		}
+	}
+}
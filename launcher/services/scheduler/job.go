@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a job and the result of its last run.
+type JobStatus struct {
+	Name string
+	// Running reports a run in progress.
+	Running bool
+	// Runs and Failures count the finished runs and the failed ones.
+	Runs     int
+	Failures int
+	// Skipped counts the runs skipped by OverlapSkip.
+	Skipped int
+	// LastRun is when the last finished run started.
+	LastRun      time.Time
+	LastDuration time.Duration
+	// LastError is the error of the last finished run, nil if it succeeded.
+	LastError error
+	// NextRun is when the job is due next, zero if it is not scheduled.
+	NextRun time.Time
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       func(ctx context.Context) error
+
+	jitter  time.Duration
+	timeout time.Duration
+	overlap OverlapPolicy
+
+	mu     sync.Mutex
+	status JobStatus
+	// queued counts the runs queued by OverlapQueue
+	queued int
+}
+
+// call runs the job func, recovering a panic.
+func (j *job) call(ctx context.Context) (err error) {
+	defer recoverJob(j.name, &err)
+	return j.fn(ctx)
+}
+
+// recoverJob turns a panic of the job into a *PanicError.
+func recoverJob(name string, err *error) {
+	if v := recover(); v != nil {
+		*err = &PanicError{Job: name, Value: v, Stack: debug.Stack()}
+	}
+}
+
+func (j *job) setNextRun(t time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.NextRun = t
+}
+
+// snapshot returns a copy of the job status.
+func (j *job) snapshot() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	st := j.status
+	st.Name = j.name
+	return st
+}
@@ -0,0 +1,6 @@
+package scheduler
+
+type logger interface {
+	Infof(format string, args ...any)
+	Errorf(format string, args ...any)
+}
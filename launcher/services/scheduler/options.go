@@ -0,0 +1,55 @@
+package scheduler
+
+import "time"
+
+type Option func(*Scheduler)
+
+// WithName sets the service name. Default: scheduler.
+func WithName(v string) Option {
+	return func(s *Scheduler) {
+		if v == "" {
+			return
+		}
+		s.name = v
+	}
+}
+
+// WithHealthCheckInterval sets the health check polling interval. Default: 10 seconds.
+func WithHealthCheckInterval(v time.Duration) Option {
+	return func(s *Scheduler) {
+		if v == 0 {
+			return
+		}
+		s.healthCheckInterval = v
+	}
+}
+
+// OverlapPolicy decides what happens when a job is due while its previous run
+// is still in progress.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run. It is the default.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the job again once the run in progress finished, as
+	// many times as it was due meanwhile.
+	OverlapQueue
+)
+
+type JobOption func(*job)
+
+// WithJitter delays every run by a random duration up to v, to spread the
+// runs of many instances.
+func WithJitter(v time.Duration) JobOption {
+	return func(j *job) { j.jitter = v }
+}
+
+// WithTimeout bounds every run; its context is done once v elapsed.
+func WithTimeout(v time.Duration) JobOption {
+	return func(j *job) { j.timeout = v }
+}
+
+// WithOverlap sets the overlap policy. Default: OverlapSkip.
+func WithOverlap(v OverlapPolicy) JobOption {
+	return func(j *job) { j.overlap = v }
+}
@@ -0,0 +1,326 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/tkcrm/mx/mxtypes"
+)
+
+var (
+	defaultName                = "scheduler"
+	defaultHealthCheckInterval = time.Second * 10
+)
+
+var (
+	// ErrJobExists is returned by Add for a job name that is already taken.
+	ErrJobExists = errors.New("job already added")
+	// ErrRunning is returned by Add once the scheduler was started.
+	ErrRunning = errors.New("scheduler is running")
+)
+
+// PanicError is the error of a job run that panicked.
+type PanicError struct {
+	Job   string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job [%s] panicked: %v", e.Job, e.Value)
+}
+
+// Scheduler is a service running jobs on cron schedules or at fixed
+// intervals. It reports the last run of every job as its health check.
+type Scheduler struct {
+	log logger
+
+	// default: scheduler
+	name string
+	// default: 10 seconds
+	healthCheckInterval time.Duration
+
+	mu   sync.Mutex
+	jobs []*job
+	// current is the run of the scheduler between Start and Stop
+	current *run
+}
+
+// run holds the state of a single Start call.
+type run struct {
+	// done is closed by Stop to stop scheduling
+	done     chan struct{}
+	doneOnce sync.Once
+	// ctx is the context of the job runs; cancelled by Stop once its
+	// deadline passed
+	ctx    context.Context //nolint:containedctx
+	cancel context.CancelFunc
+	// runs tracks the job runs in progress
+	runs sync.WaitGroup
+	// finished is closed once Start returned
+	finished chan struct{}
+}
+
+func (r *run) stop() { r.doneOnce.Do(func() { close(r.done) }) }
+
+// New returns a scheduler without jobs.
+func New(log logger, opts ...Option) *Scheduler {
+	s := &Scheduler{log: log}
+	for _, o := range opts {
+		o(s)
+	}
+
+	if s.name == "" {
+		s.name = defaultName
+	}
+
+	if s.healthCheckInterval == 0 {
+		s.healthCheckInterval = defaultHealthCheckInterval
+	}
+
+	return s
+}
+
+// Add adds a job running fn on schedule. Jobs must be added before the
+// scheduler starts and their names must be unique.
+func (s *Scheduler) Add(name string, schedule Schedule, fn func(ctx context.Context) error, opts ...JobOption) error {
+	if name == "" || schedule == nil || fn == nil {
+		return errors.New("job name, schedule and func are required")
+	}
+
+	j := &job{name: name, schedule: schedule, fn: fn}
+	for _, o := range opts {
+		o(j)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil {
+		return ErrRunning
+	}
+	for _, other := range s.jobs {
+		if other.name == name {
+			return fmt.Errorf("%w: %s", ErrJobExists, name)
+		}
+	}
+
+	s.jobs = append(s.jobs, j)
+	return nil
+}
+
+// Name of the service.
+func (s *Scheduler) Name() string { return s.name }
+
+// Start schedules the jobs until ctx is done or Stop is called, then waits
+// for the runs in progress to finish.
+func (s *Scheduler) Start(ctx context.Context) error {
+	r := &run{
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	r.ctx, r.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	defer r.cancel()
+	defer close(r.finished)
+
+	s.mu.Lock()
+	if s.current != nil {
+		s.mu.Unlock()
+		return ErrRunning
+	}
+	s.current = r
+	jobs := s.jobs
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.current = nil
+		s.mu.Unlock()
+	}()
+
+	var loops sync.WaitGroup
+	for _, j := range jobs {
+		loops.Go(func() { s.loop(ctx, r, j) })
+	}
+	loops.Wait()
+
+	// let the runs in progress finish; Stop cancels them past its deadline
+	r.runs.Wait()
+
+	return nil
+}
+
+// Stop stops scheduling and waits for the runs in progress until ctx is
+// done, then cancels them.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	r := s.current
+	s.mu.Unlock()
+
+	if r == nil {
+		return nil
+	}
+
+	r.stop()
+
+	select {
+	case <-r.finished:
+	case <-ctx.Done():
+		r.cancel()
+	}
+
+	return nil
+}
+
+// loop triggers j on its schedule until ctx is done or the run is stopped.
+func (s *Scheduler) loop(ctx context.Context, r *run, j *job) {
+	defer j.setNextRun(time.Time{})
+
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.log.Infof("job [%s] has no next run, it is no longer scheduled", j.name)
+			return
+		}
+
+		delay := time.Until(next)
+		if j.jitter > 0 {
+			delay += rand.N(j.jitter)
+		}
+		j.setNextRun(time.Now().Add(delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			s.trigger(r, j)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-r.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// trigger starts a run of j, or applies its overlap policy when a run is
+// still in progress.
+func (s *Scheduler) trigger(r *run, j *job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.Running {
+		if j.overlap == OverlapQueue {
+			j.queued++
+			return
+		}
+		j.status.Skipped++
+		s.log.Infof("job [%s] is still running, run skipped", j.name)
+		return
+	}
+
+	j.status.Running = true
+	r.runs.Go(func() {
+		for {
+			s.execute(r.ctx, j)
+
+			j.mu.Lock()
+			if j.queued == 0 {
+				j.status.Running = false
+				j.mu.Unlock()
+				return
+			}
+			j.queued--
+			j.mu.Unlock()
+		}
+	})
+}
+
+// execute runs j once, bounded by its timeout, and records the result.
+func (s *Scheduler) execute(ctx context.Context, j *job) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := j.call(ctx)
+	duration := time.Since(start)
+
+	j.mu.Lock()
+	j.status.Runs++
+	j.status.LastRun = start
+	j.status.LastDuration = duration
+	j.status.LastError = err
+	if err != nil {
+		j.status.Failures++
+	}
+	j.mu.Unlock()
+
+	if err != nil {
+		s.log.Errorf("job [%s] failed: %s", j.name, err)
+	}
+}
+
+// Jobs returns the status of all jobs, in the order they were added.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	jobs := s.jobs
+	s.mu.Unlock()
+
+	res := make([]JobStatus, len(jobs))
+	for i, j := range jobs {
+		res[i] = j.snapshot()
+	}
+	return res
+}
+
+// Interval returns the health check polling interval.
+func (s *Scheduler) Interval() time.Duration { return s.healthCheckInterval }
+
+// Healthy fails while the last run of any job failed.
+func (s *Scheduler) Healthy(_ context.Context) error {
+	var errs []error
+	for _, st := range s.Jobs() {
+		if st.LastError != nil {
+			errs = append(errs, fmt.Errorf("job [%s] last run failed: %w", st.Name, st.LastError))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HealthDetails returns the status of every job.
+func (s *Scheduler) HealthDetails() map[string]any {
+	res := make(map[string]any)
+	for _, st := range s.Jobs() {
+		details := map[string]any{
+			"running":  st.Running,
+			"runs":     st.Runs,
+			"failures": st.Failures,
+			"skipped":  st.Skipped,
+		}
+		if !st.LastRun.IsZero() {
+			details["last_run"] = st.LastRun
+			details["last_duration"] = st.LastDuration.String()
+		}
+		if st.LastError != nil {
+			details["last_error"] = st.LastError.Error()
+		}
+		if !st.NextRun.IsZero() {
+			details["next_run"] = st.NextRun
+		}
+		res[st.Name] = details
+	}
+	return res
+}
+
+var (
+	_ mxtypes.IService       = (*Scheduler)(nil)
+	_ mxtypes.HealthChecker  = (*Scheduler)(nil)
+	_ mxtypes.HealthDetailer = (*Scheduler)(nil)
+)
@@ -0,0 +1,233 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher/services/scheduler"
+	"github.com/tkcrm/mx/logger"
+)
+
+func newScheduler() *scheduler.Scheduler {
+	return scheduler.New(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal)))
+}
+
+// start runs s until the test function returns.
+func start(t *testing.T, s *scheduler.Scheduler) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Start(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Start error: %v", err)
+		}
+	})
+}
+
+func jobStatus(t *testing.T, s *scheduler.Scheduler, name string) scheduler.JobStatus {
+	t.Helper()
+
+	for _, st := range s.Jobs() {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("job %s not found", name)
+	return scheduler.JobStatus{}
+}
+
+func TestScheduler_Every(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := newScheduler()
+
+		var runs atomic.Int32
+		if err := s.Add("tick", scheduler.Every(time.Minute), func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		start(t, s)
+
+		time.Sleep(3*time.Minute + time.Second)
+		synctest.Wait()
+
+		if got := runs.Load(); got != 3 {
+			t.Errorf("runs = %d; want 3", got)
+		}
+		st := jobStatus(t, s, "tick")
+		if st.Runs != 3 || st.LastError != nil || st.NextRun.IsZero() {
+			t.Errorf("status = %+v; want 3 successful runs and a next run", st)
+		}
+	})
+}
+
+func TestScheduler_Overlap(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := newScheduler()
+
+		var skipRuns, queueRuns atomic.Int32
+		slow := func(runs *atomic.Int32) func(context.Context) error {
+			return func(context.Context) error {
+				runs.Add(1)
+				time.Sleep(150 * time.Second)
+				return nil
+			}
+		}
+		_ = s.Add("skip", scheduler.Every(time.Minute), slow(&skipRuns))
+		_ = s.Add("queue", scheduler.Every(time.Minute), slow(&queueRuns), scheduler.WithOverlap(scheduler.OverlapQueue))
+		start(t, s)
+
+		// due at 1m, 2m and 3m; each run takes 2.5m
+		time.Sleep(3*time.Minute + time.Second)
+		synctest.Wait()
+
+		if st := jobStatus(t, s, "skip"); skipRuns.Load() != 1 || st.Skipped != 2 {
+			t.Errorf("skip: runs = %d, skipped = %d; want 1 run and 2 skipped", skipRuns.Load(), st.Skipped)
+		}
+		// the run due at 2m was queued and started at 3m30s
+		if st := jobStatus(t, s, "queue"); queueRuns.Load() != 1 || !st.Running {
+			t.Errorf("queue: runs = %d; want 1 running", queueRuns.Load())
+		}
+
+		time.Sleep(30 * time.Second)
+		synctest.Wait()
+		if got := queueRuns.Load(); got != 2 {
+			t.Errorf("queue: runs = %d; want the queued run to start", got)
+		}
+	})
+}
+
+func TestScheduler_TimeoutAndPanic(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := newScheduler()
+
+		_ = s.Add("slow", scheduler.Every(time.Minute), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, scheduler.WithTimeout(10*time.Second))
+		_ = s.Add("crash", scheduler.Every(time.Minute), func(context.Context) error {
+			panic("nil map")
+		})
+		start(t, s)
+
+		time.Sleep(time.Minute + 11*time.Second)
+		synctest.Wait()
+
+		if st := jobStatus(t, s, "slow"); !errors.Is(st.LastError, context.DeadlineExceeded) || st.LastDuration != 10*time.Second {
+			t.Errorf("slow: status = %+v; want a run timed out after 10s", st)
+		}
+
+		var panicErr *scheduler.PanicError
+		if st := jobStatus(t, s, "crash"); !errors.As(st.LastError, &panicErr) || panicErr.Value != "nil map" || len(panicErr.Stack) == 0 {
+			t.Errorf("crash: last error = %v; want a recovered panic with its stack", st.LastError)
+		}
+
+		// the scheduler survives the panic and keeps running the job
+		time.Sleep(time.Minute)
+		synctest.Wait()
+		if st := jobStatus(t, s, "crash"); st.Runs != 2 || st.Failures != 2 {
+			t.Errorf("crash: status = %+v; want 2 failed runs", st)
+		}
+	})
+}
+
+func TestScheduler_Healthy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := newScheduler()
+
+		var fail atomic.Bool
+		fail.Store(true)
+		_ = s.Add("sync", scheduler.Every(time.Minute), func(context.Context) error {
+			if fail.Load() {
+				return errors.New("upstream down")
+			}
+			return nil
+		})
+		start(t, s)
+
+		if err := s.Healthy(context.Background()); err != nil {
+			t.Errorf("Healthy before any run = %v; want nil", err)
+		}
+
+		time.Sleep(time.Minute + time.Second)
+		synctest.Wait()
+		if err := s.Healthy(context.Background()); err == nil {
+			t.Error("Healthy after a failed run = nil; want error")
+		}
+		details := s.HealthDetails()["sync"].(map[string]any)
+		if details["last_error"] != "upstream down" || details["failures"] != 1 {
+			t.Errorf("HealthDetails = %v; want the failed run", details)
+		}
+
+		fail.Store(false)
+		time.Sleep(time.Minute)
+		synctest.Wait()
+		if err := s.Healthy(context.Background()); err != nil {
+			t.Errorf("Healthy after a successful run = %v; want nil", err)
+		}
+	})
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := newScheduler()
+
+		var finished, cancelled atomic.Bool
+		_ = s.Add("graceful", scheduler.Every(time.Minute), func(context.Context) error {
+			time.Sleep(5 * time.Second)
+			finished.Store(true)
+			return nil
+		})
+		_ = s.Add("hanging", scheduler.Every(time.Minute), func(ctx context.Context) error {
+			<-ctx.Done()
+			cancelled.Store(true)
+			return ctx.Err()
+		})
+
+		done := make(chan error, 1)
+		go func() { done <- s.Start(context.Background()) }()
+
+		time.Sleep(time.Minute + time.Second)
+
+		// the runs in progress get until the stop deadline, then are cancelled
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("Stop error: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("Start error: %v", err)
+		}
+
+		if !finished.Load() || !cancelled.Load() {
+			t.Errorf("finished = %t, cancelled = %t; want the graceful run finished and the hanging one cancelled", finished.Load(), cancelled.Load())
+		}
+		if err := s.Add("late", scheduler.Every(time.Minute), func(context.Context) error { return nil }); err != nil {
+			t.Errorf("Add after Stop error = %v; want nil", err)
+		}
+	})
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := newScheduler()
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Add("a", scheduler.Every(time.Minute), noop); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add("a", scheduler.Every(time.Minute), noop); !errors.Is(err, scheduler.ErrJobExists) {
+		t.Errorf("Add duplicate error = %v; want ErrJobExists", err)
+	}
+	if err := s.Add("b", nil, noop); err == nil {
+		t.Error("Add without schedule error = nil; want error")
+	}
+}
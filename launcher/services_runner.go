@@ -2,7 +2,12 @@ package launcher
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
 
+	"github.com/tkcrm/mx/launcher/ops"
 	"github.com/tkcrm/mx/logger"
 	"github.com/tkcrm/mx/mxtypes"
 )
@@ -13,11 +18,33 @@ const (
 	RunnerServicesSequenceNone = iota
 	RunnerServicesSequenceFifo
 	RunnerServicesSequenceLifo
+	// RunnerServicesSequenceReversePriority stops the services in stop
+	// priority groups: priority 0 first, then the groups in descending
+	// priority, the services of a group concurrently. The stop priority is
+	// the startup priority unless set with WithStopPriority.
+	RunnerServicesSequenceReversePriority
+)
+
+var (
+	// ErrServiceNotFound is returned when no service with the given name is registered.
+	ErrServiceNotFound = ops.ErrServiceNotFound
+	// ErrServiceExists is returned by Add when a service with the same name is already registered.
+	ErrServiceExists = errors.New("service already registered")
+	// ErrNotRunning is returned by operations that need a running launcher.
+	ErrNotRunning = ops.ErrNotRunning
 )
 
 type IServicesRunner interface {
 	// Register services
 	Register(services ...*Service)
+	// Add registers a service and, if the launcher is running, starts it at once.
+	Add(svc *Service) error
+	// Remove gracefully stops a service and deregisters it.
+	Remove(name string) error
+	// Restart stops a running service and starts it again.
+	Restart(ctx context.Context, name string) error
+	// Stop gracefully stops a running service without deregistering it.
+	Stop(name string) error
 	// Services return all registered services
 	Services() []*Service
 	// Get returns a registered service by name, or false if not found.
@@ -25,9 +52,24 @@ type IServicesRunner interface {
 }
 
 type servicesRunner struct {
-	logger   logger.Logger
+	logger logger.Logger
+	ctx    context.Context //nolint:containedctx
+
+	mu       sync.RWMutex
 	services []*Service
-	ctx      context.Context //nolint:containedctx
+	// launch starts a service within the running launcher, onDemand for a
+	// restart by Restart; nil while the launcher is not running.
+	launch func(svc *Service, deps []*Service, onDemand bool)
+	// changed is closed and replaced on every change of the services list.
+	changed chan struct{}
+	// publish receives the state transitions of all registered services.
+	publish func(LifecycleEvent)
+	// observeHook receives the hook runs of all registered services.
+	observeHook func(hookRun)
+	// panics is the panic policy of all registered services.
+	panics PanicPolicy
+	// budget is the restart budget shared by all registered services.
+	budget *restartBudget
 }
 
 func newServicesRunner(ctx context.Context, logger logger.Logger) *servicesRunner {
@@ -35,6 +77,7 @@ func newServicesRunner(ctx context.Context, logger logger.Logger) *servicesRunne
 		logger:   logger,
 		services: make([]*Service, 0),
 		ctx:      ctx,
+		changed:  make(chan struct{}),
 	}
 }
 
@@ -50,6 +93,26 @@ func (s *servicesRunner) registerService(svc *Service) {
 		return
 	}
 
+	if err := s.prepareService(svc); err != nil {
+		s.logger.Errorf("service [%s] was skipped because it has validation error: %s", svc.Name(), err)
+		return
+	}
+
+	// skip service if it disabled
+	if !svc.Options().Enabled {
+		s.logger.Infof("service [%s] was skipped because it is disabled", svc.Name())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.services = append(s.services, svc)
+	s.notifyLocked()
+}
+
+// prepareService sets the runner defaults on the service options and validates them.
+func (s *servicesRunner) prepareService(svc *Service) error {
 	svcOpts := svc.Options()
 
 	// set context
@@ -60,41 +123,277 @@ func (s *servicesRunner) registerService(svc *Service) {
 	// set logger
 	svcOpts.Logger = s.logger
 
+	// report state transitions
+	svc.onStateChange = s.publish
+	svc.onHook = s.observeHook
+	svc.panics = s.panics
+	svc.budget = s.budget
+
 	// validate service options
-	if err := svcOpts.Validate(); err != nil {
-		s.logger.Errorf("service [%s] was skipped because it has validation error: %s", svc.Name(), err)
-		return
+	return svcOpts.Validate()
+}
+
+// Add registers a service at runtime. When the launcher is running the
+// service is started immediately, once its dependencies are ready. Service
+// names must be unique and all dependencies must already be registered.
+// A disabled service is skipped, as with Register.
+func (s *servicesRunner) Add(svc *Service) error {
+	if svc == nil {
+		return errors.New("nil service")
 	}
 
-	// skip service if it disabled
-	if !svcOpts.Enabled {
+	if err := s.prepareService(svc); err != nil {
+		return fmt.Errorf("service [%s] validation error: %w", svc.Name(), err)
+	}
+
+	if !svc.Options().Enabled {
 		s.logger.Infof("service [%s] was skipped because it is disabled", svc.Name())
-		return
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexLocked(svc.Name()) >= 0 {
+		return fmt.Errorf("%w: [%s]", ErrServiceExists, svc.Name())
+	}
+
+	deps, err := s.dependenciesLocked(svc)
+	if err != nil {
+		return err
 	}
 
 	s.services = append(s.services, svc)
+	s.notifyLocked()
+	svc.setRemoved(false)
+
+	// launch under the lock, so the launcher cannot begin shutting down
+	// between the check and the start
+	if s.launch != nil {
+		s.launch(svc, deps, false)
+	}
+
+	return nil
 }
 
+// Remove deregisters the service and, if it was started, stops it gracefully:
+// its context is cancelled, Start is awaited for up to ShutdownTimeout and then
+// Stop is called. A service other services depend on cannot be removed.
+func (s *servicesRunner) Remove(name string) error {
+	s.mu.Lock()
+
+	idx := s.indexLocked(name)
+	if idx < 0 {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: [%s]", ErrServiceNotFound, name)
+	}
+
+	for _, other := range s.services {
+		if slices.Contains(other.Options().DependsOn, name) {
+			s.mu.Unlock()
+			return fmt.Errorf("service [%s] cannot be removed: service [%s] depends on it", name, other.Name())
+		}
+	}
+
+	svc := s.services[idx]
+	s.services = slices.Delete(s.services, idx, idx+1)
+	s.notifyLocked()
+	s.mu.Unlock()
+
+	svc.ctlMu.Lock()
+	defer svc.ctlMu.Unlock()
+
+	svc.setRemoved(true)
+	svc.halt()
+
+	return svc.Stop()
+}
+
+// Restart bounces a single service within the running launcher. The service is
+// stopped the way the launcher stops it on shutdown — its context is cancelled,
+// Start is awaited for up to ShutdownTimeout, then Stop is called — and started
+// again through the usual state machine, readiness gating and StartupTimeout.
+// Restart returns once the service is ready again, or with the error it failed
+// to start with. A failure to start is returned to the caller only and leaves
+// the service failed without shutting the launcher down; once ready, the
+// service fails like any other. A failed Stop is logged and does not prevent
+// the new start.
+func (s *servicesRunner) Restart(ctx context.Context, name string) error {
+	svc, ok := s.Get(name)
+	if !ok {
+		return fmt.Errorf("%w: [%s]", ErrServiceNotFound, name)
+	}
+
+	svc.ctlMu.Lock()
+	defer svc.ctlMu.Unlock()
+
+	s.mu.RLock()
+	running := s.launch != nil
+	s.mu.RUnlock()
+	if !running {
+		return fmt.Errorf("%w: cannot restart service [%s]", ErrNotRunning, name)
+	}
+
+	s.logger.Infof("restarting service [%s]", name)
+
+	svc.setReason(EventReasonRestartRequested)
+	defer svc.setReason("")
+
+	svc.halt()
+	if err := svc.Stop(); err != nil {
+		s.logger.Warnf("service [%s] failed to stop on restart: %s", name, err)
+	}
+
+	svc.resetReady()
+	svc.restarts.Add(1)
+
+	if err := s.relaunch(svc); err != nil {
+		return err
+	}
+
+	select {
+	case <-svc.Ready():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if svc.State() == ServiceStateFailed {
+		if err := svc.LastError(); err != nil {
+			return fmt.Errorf("failed to restart service [%s]: %w", name, err)
+		}
+		return fmt.Errorf("failed to restart service [%s]", name)
+	}
+
+	return nil
+}
+
+// Stop stops a single service within the running launcher the way Restart
+// does, without starting it again. The service stays registered, so Restart
+// can start it later. A service a running service depends on cannot be
+// stopped.
+func (s *servicesRunner) Stop(name string) error {
+	svc, ok := s.Get(name)
+	if !ok {
+		return fmt.Errorf("%w: [%s]", ErrServiceNotFound, name)
+	}
+
+	svc.ctlMu.Lock()
+	defer svc.ctlMu.Unlock()
+
+	s.mu.RLock()
+	running := s.launch != nil
+	var dependent *Service
+	for _, other := range s.services {
+		if slices.Contains(other.Options().DependsOn, name) && other.State() == ServiceStateRunning {
+			dependent = other
+			break
+		}
+	}
+	s.mu.RUnlock()
+	if !running {
+		return fmt.Errorf("%w: cannot stop service [%s]", ErrNotRunning, name)
+	}
+	if dependent != nil {
+		return fmt.Errorf("service [%s] cannot be stopped: service [%s] depends on it", name, dependent.Name())
+	}
+
+	s.logger.Infof("stopping service [%s] on request", name)
+
+	svc.setReason(EventReasonStopRequested)
+	defer svc.setReason("")
+
+	svc.halt()
+
+	return svc.Stop()
+}
+
+// relaunch starts a registered service again within the running launcher.
+func (s *servicesRunner) relaunch(svc *Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.launch == nil {
+		return fmt.Errorf("%w: cannot start service [%s]", ErrNotRunning, svc.Name())
+	}
+
+	if !slices.Contains(s.services, svc) {
+		return fmt.Errorf("%w: [%s]", ErrServiceNotFound, svc.Name())
+	}
+
+	deps, err := s.dependenciesLocked(svc)
+	if err != nil {
+		return err
+	}
+
+	s.launch(svc, deps, true)
+
+	return nil
+}
+
+// Services returns a snapshot of the registered services.
 func (s *servicesRunner) Services() []*Service {
-	return s.services
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.services)
 }
 
 // Get returns a registered service by name, or false if not found.
 func (s *servicesRunner) Get(name string) (*Service, bool) {
-	for _, svc := range s.services {
-		if svc.Name() == name {
-			return svc, true
-		}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if idx := s.indexLocked(name); idx >= 0 {
+		return s.services[idx], true
 	}
 	return nil, false
 }
 
+// setLaunch sets the func used to start services added at runtime. Passing
+// nil stops Add from starting services.
+func (s *servicesRunner) setLaunch(fn func(svc *Service, deps []*Service, onDemand bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.launch = fn
+}
+
+// changes returns a channel that is closed on the next change of the services list.
+func (s *servicesRunner) changes() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changed
+}
+
+// dependenciesLocked resolves the declared dependencies of svc.
+func (s *servicesRunner) dependenciesLocked(svc *Service) ([]*Service, error) {
+	deps := make([]*Service, 0, len(svc.Options().DependsOn))
+	for _, name := range svc.Options().DependsOn {
+		idx := s.indexLocked(name)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: service [%s] depends on [%s]", ErrUnknownDependency, svc.Name(), name)
+		}
+		deps = append(deps, s.services[idx])
+	}
+	return deps, nil
+}
+
+func (s *servicesRunner) indexLocked(name string) int {
+	return slices.IndexFunc(s.services, func(svc *Service) bool { return svc.Name() == name })
+}
+
+func (s *servicesRunner) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
 // hcServices return services that implement the HealthChecker interface.
 func (s *servicesRunner) hcServices() []mxtypes.HealthChecker {
 	services := []mxtypes.HealthChecker{}
-	for _, svc := range s.services {
+	for _, svc := range s.Services() {
+		if svc.internal {
+			continue
+		}
 		if svc.Options().HealthChecker != nil {
-			services = append(services, svc.Options().HealthChecker)
+			services = append(services, newClassifiedHealthChecker(svc.Options()))
 		}
 	}
 	return services
@@ -102,9 +401,22 @@ func (s *servicesRunner) hcServices() []mxtypes.HealthChecker {
 
 // stateProviders returns all registered services as StateProvider.
 func (s *servicesRunner) stateProviders() []mxtypes.StateProvider {
-	providers := make([]mxtypes.StateProvider, len(s.services))
-	for i, svc := range s.services {
-		providers[i] = svc
+	providers := []mxtypes.StateProvider{}
+	for _, svc := range s.Services() {
+		if svc.internal {
+			continue
+		}
+		providers = append(providers, svc)
 	}
 	return providers
 }
+
+// opsRegistry exposes the services runner to the ops health checker, so the
+// probes follow services added or removed at runtime.
+type opsRegistry struct{ runner *servicesRunner }
+
+func (r opsRegistry) HealthCheckers() []mxtypes.HealthChecker { return r.runner.hcServices() }
+
+func (r opsRegistry) StateProviders() []mxtypes.StateProvider { return r.runner.stateProviders() }
+
+func (r opsRegistry) Changed() <-chan struct{} { return r.runner.changes() }
@@ -0,0 +1,528 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+)
+
+// runLauncher starts ln.Run in the background and waits for startup to settle.
+func runLauncher(t *testing.T, ln launcher.ILauncher) <-chan error {
+	t.Helper()
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+	synctest.Wait()
+	return errCh
+}
+
+func TestServicesRunner_Add_StartsWhileRunning(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var started, stopped atomic.Bool
+
+		ln := newTestLauncher()
+		errCh := runLauncher(t, ln)
+
+		err := ln.ServicesRunner().Add(launcher.NewService(
+			launcher.WithServiceName("tenant-1"),
+			launcher.WithStart(func(ctx context.Context) error {
+				started.Store(true)
+				<-ctx.Done()
+				return nil
+			}),
+			launcher.WithStop(func(context.Context) error {
+				stopped.Store(true)
+				return nil
+			}),
+		))
+		if err != nil {
+			t.Fatalf("Add error: %v", err)
+		}
+		synctest.Wait()
+
+		svc, ok := ln.ServicesRunner().Get("tenant-1")
+		if !ok {
+			t.Fatal("added service is not registered")
+		}
+		if !started.Load() || svc.State() != launcher.ServiceStateRunning {
+			t.Fatalf("added service not running: started=%v state=%v", started.Load(), svc.State())
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if !stopped.Load() {
+			t.Fatal("service added at runtime was not stopped on shutdown")
+		}
+	})
+}
+
+// The failures of services added at runtime are all returned by Run, even
+// when they outnumber the services registered before it.
+func TestServicesRunner_Add_Failures(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		fail := make(chan struct{})
+
+		ln := newTestLauncher(launcher.WithLogger(quietExtended()))
+		errCh := runLauncher(t, ln)
+
+		var errs []error
+		for i := range 3 {
+			err := fmt.Errorf("tenant %d crashed", i)
+			errs = append(errs, err)
+			if err := ln.ServicesRunner().Add(launcher.NewService(
+				launcher.WithServiceName(fmt.Sprintf("tenant-%d", i)),
+				launcher.WithStart(func(context.Context) error {
+					<-fail
+					return err
+				}),
+				launcher.WithStop(noopStop),
+			)); err != nil {
+				t.Fatalf("Add error: %v", err)
+			}
+		}
+		synctest.Wait()
+		close(fail)
+
+		err := <-errCh
+		for _, want := range errs {
+			if !errors.Is(err, want) {
+				t.Errorf("Run error = %v; want %v", err, want)
+			}
+		}
+	})
+}
+
+func TestServicesRunner_Add_BeforeRun(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var started atomic.Bool
+
+		ln := newTestLauncher()
+		err := ln.ServicesRunner().Add(launcher.NewService(
+			launcher.WithServiceName("early"),
+			launcher.WithStart(func(ctx context.Context) error {
+				started.Store(true)
+				<-ctx.Done()
+				return nil
+			}),
+			launcher.WithStop(noopStop),
+		))
+		if err != nil {
+			t.Fatalf("Add error: %v", err)
+		}
+		synctest.Wait()
+		if started.Load() {
+			t.Fatal("service added before Run must not start until Run")
+		}
+
+		errCh := runLauncher(t, ln)
+		if !started.Load() {
+			t.Fatal("service added before Run was not started by Run")
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+// A service added at runtime waits for its dependencies like any other.
+func TestServicesRunner_Add_WaitsForDependencies(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var started atomic.Bool
+
+		db := newReadinessService("db")
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(launcher.NewService(launcher.WithService(db)))
+		errCh := runLauncher(t, ln)
+
+		err := ln.ServicesRunner().Add(launcher.NewService(
+			launcher.WithServiceName("consumer"),
+			launcher.WithDependsOn("db"),
+			launcher.WithStart(func(ctx context.Context) error {
+				started.Store(true)
+				<-ctx.Done()
+				return nil
+			}),
+			launcher.WithStop(noopStop),
+		))
+		if err != nil {
+			t.Fatalf("Add error: %v", err)
+		}
+		synctest.Wait()
+		if started.Load() {
+			t.Fatal("consumer started before db was ready")
+		}
+
+		db.markReady()
+		synctest.Wait()
+		if !started.Load() {
+			t.Fatal("consumer did not start after db became ready")
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+func TestServicesRunner_Add_Errors(t *testing.T) {
+	ln := newTestLauncher()
+	runner := ln.ServicesRunner()
+
+	if err := runner.Add(nil); err == nil {
+		t.Error("Add(nil) error = nil; want error")
+	}
+
+	invalid := launcher.NewService(launcher.WithServiceName("invalid"), launcher.WithStart(blockingStart))
+	if err := runner.Add(invalid); err == nil {
+		t.Error("Add(invalid) error = nil; want validation error")
+	}
+
+	newSvc := func(name string, deps ...string) *launcher.Service {
+		return launcher.NewService(
+			launcher.WithServiceName(name),
+			launcher.WithDependsOn(deps...),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+		)
+	}
+
+	if err := runner.Add(newSvc("a")); err != nil {
+		t.Fatalf("Add(a) error: %v", err)
+	}
+	if err := runner.Add(newSvc("a")); !errors.Is(err, launcher.ErrServiceExists) {
+		t.Errorf("Add(duplicate) error = %v; want ErrServiceExists", err)
+	}
+	if err := runner.Add(newSvc("b", "missing")); !errors.Is(err, launcher.ErrUnknownDependency) {
+		t.Errorf("Add(unknown dep) error = %v; want ErrUnknownDependency", err)
+	}
+
+	disabled := launcher.NewService(
+		launcher.WithServiceName("disabled"),
+		launcher.WithEnabled(false),
+		launcher.WithStart(blockingStart),
+		launcher.WithStop(noopStop),
+	)
+	if err := runner.Add(disabled); err != nil {
+		t.Errorf("Add(disabled) error = %v; want nil", err)
+	}
+	if _, ok := runner.Get("disabled"); ok {
+		t.Error("disabled service must be skipped")
+	}
+}
+
+func TestServicesRunner_Remove_StopsGracefully(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var startReturned, stopped atomic.Bool
+
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("keep"),
+			launcher.WithStart(blockingStart),
+			launcher.WithStop(noopStop),
+		))
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("worker"),
+			launcher.WithStart(func(ctx context.Context) error {
+				<-ctx.Done()
+				startReturned.Store(true)
+				return nil
+			}),
+			launcher.WithStop(func(context.Context) error {
+				stopped.Store(true)
+				return nil
+			}),
+		))
+		errCh := runLauncher(t, ln)
+
+		svc, _ := ln.ServicesRunner().Get("worker")
+		if err := ln.ServicesRunner().Remove("worker"); err != nil {
+			t.Fatalf("Remove error: %v", err)
+		}
+
+		if !startReturned.Load() || !stopped.Load() {
+			t.Fatalf("removed service not stopped: startReturned=%v stopped=%v", startReturned.Load(), stopped.Load())
+		}
+		if svc.State() != launcher.ServiceStateStopped {
+			t.Fatalf("removed service state = %v; want stopped", svc.State())
+		}
+		if _, ok := ln.ServicesRunner().Get("worker"); ok {
+			t.Fatal("removed service is still registered")
+		}
+
+		keep, _ := ln.ServicesRunner().Get("keep")
+		if keep.State() != launcher.ServiceStateRunning {
+			t.Fatalf("other service state = %v; want running", keep.State())
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+// A service removed while it waits for its dependencies never starts.
+func TestServicesRunner_Remove_WaitingForDependency(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var apiStarted atomic.Bool
+		dbReady := make(chan struct{})
+
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(
+			launcher.NewService(
+				launcher.WithServiceName("db"),
+				launcher.WithReadiness(dbReady),
+				launcher.WithStart(blockingStart),
+				launcher.WithStop(noopStop),
+			),
+			launcher.NewService(
+				launcher.WithServiceName("api"),
+				launcher.WithDependsOn("db"),
+				launcher.WithStart(func(ctx context.Context) error {
+					apiStarted.Store(true)
+					<-ctx.Done()
+					return nil
+				}),
+				launcher.WithStop(noopStop),
+			),
+		)
+		errCh := runLauncher(t, ln)
+
+		api, _ := ln.ServicesRunner().Get("api")
+		if err := ln.ServicesRunner().Remove("api"); err != nil {
+			t.Fatalf("Remove error: %v", err)
+		}
+
+		close(dbReady)
+		synctest.Wait()
+
+		if apiStarted.Load() {
+			t.Fatal("removed service started once its dependency became ready")
+		}
+		if api.State() != launcher.ServiceStateIdle {
+			t.Errorf("removed service state = %v; want idle", api.State())
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+func TestServicesRunner_Remove_Errors(t *testing.T) {
+	ln := newTestLauncher()
+	runner := ln.ServicesRunner()
+
+	if err := runner.Remove("missing"); !errors.Is(err, launcher.ErrServiceNotFound) {
+		t.Errorf("Remove(missing) error = %v; want ErrServiceNotFound", err)
+	}
+
+	runner.Register(
+		launcher.NewService(launcher.WithServiceName("db"), launcher.WithStart(blockingStart), launcher.WithStop(noopStop)),
+		launcher.NewService(launcher.WithServiceName("api"), launcher.WithDependsOn("db"), launcher.WithStart(blockingStart), launcher.WithStop(noopStop)),
+	)
+
+	if err := runner.Remove("db"); err == nil {
+		t.Error("Remove(db) error = nil; want error, api depends on it")
+	}
+	if err := runner.Remove("api"); err != nil {
+		t.Errorf("Remove(api) error = %v", err)
+	}
+	if err := runner.Remove("db"); err != nil {
+		t.Errorf("Remove(db) after api error = %v", err)
+	}
+}
+
+func TestServicesRunner_AddRemove_Concurrent(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ln := newTestLauncher()
+		errCh := runLauncher(t, ln)
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Go(func() {
+				name := fmt.Sprintf("tenant-%d", i)
+				err := ln.ServicesRunner().Add(launcher.NewService(
+					launcher.WithServiceName(name),
+					launcher.WithStart(blockingStart),
+					launcher.WithStop(noopStop),
+				))
+				if err != nil {
+					t.Errorf("Add(%s) error: %v", name, err)
+					return
+				}
+				if i%2 == 0 {
+					if err := ln.ServicesRunner().Remove(name); err != nil {
+						t.Errorf("Remove(%s) error: %v", name, err)
+					}
+				}
+			})
+		}
+		wg.Wait()
+
+		if got := len(ln.ServicesRunner().Services()); got != 5 {
+			t.Fatalf("registered services = %d; want 5", got)
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	})
+}
+
+func TestServicesRunner_Restart(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var starts, stops atomic.Int32
+
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithServiceName("pool"),
+			launcher.WithStart(func(ctx context.Context) error {
+				starts.Add(1)
+				<-ctx.Done()
+				return nil
+			}),
+			launcher.WithStop(func(context.Context) error {
+				stops.Add(1)
+				return nil
+			}),
+		))
+		errCh := runLauncher(t, ln)
+
+		if err := ln.ServicesRunner().Restart(t.Context(), "pool"); err != nil {
+			t.Fatalf("Restart error: %v", err)
+		}
+		synctest.Wait()
+
+		svc, _ := ln.ServicesRunner().Get("pool")
+		if starts.Load() != 2 || stops.Load() != 1 {
+			t.Fatalf("starts=%d stops=%d; want 2 and 1", starts.Load(), stops.Load())
+		}
+		if svc.State() != launcher.ServiceStateRunning {
+			t.Fatalf("state after restart = %v; want running", svc.State())
+		}
+		if svc.Restarts() != 1 {
+			t.Fatalf("Restarts() = %d; want 1", svc.Restarts())
+		}
+
+		ln.Stop()
+		synctest.Wait()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if stops.Load() != 2 {
+			t.Fatalf("stops = %d; want 2 (restart + shutdown)", stops.Load())
+		}
+	})
+}
+
+// restartableService reports readiness through a fresh channel on every start
+// and becomes ready only when allowed to.
+type restartableService struct {
+	mu    sync.Mutex
+	ready chan struct{}
+	allow atomic.Bool
+}
+
+func (s *restartableService) Name() string { return "restartable" }
+
+func (s *restartableService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	ready := s.ready
+	s.mu.Unlock()
+	if s.allow.Load() {
+		close(ready)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (s *restartableService) Stop(context.Context) error {
+	s.mu.Lock()
+	s.ready = make(chan struct{})
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *restartableService) Ready() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+// A restart goes through readiness gating and StartupTimeout again.
+func TestServicesRunner_Restart_StartupTimeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		impl := &restartableService{ready: make(chan struct{})}
+		impl.allow.Store(true)
+
+		ln := newTestLauncher()
+		ln.ServicesRunner().Register(launcher.NewService(
+			launcher.WithService(impl),
+			launcher.WithStartupTimeout(time.Second),
+			launcher.WithShutdownTimeout(time.Second),
+		))
+		errCh := runLauncher(t, ln)
+
+		impl.allow.Store(false)
+		err := ln.ServicesRunner().Restart(t.Context(), "restartable")
+		if err == nil || !strings.Contains(err.Error(), "startup timeout") {
+			t.Fatalf("Restart error = %v; want startup timeout", err)
+		}
+
+		svc, _ := ln.ServicesRunner().Get("restartable")
+		if svc.State() != launcher.ServiceStateFailed {
+			t.Fatalf("state = %v; want failed", svc.State())
+		}
+
+		// the failed restart is returned to the caller only
+		synctest.Wait()
+		select {
+		case err := <-errCh:
+			t.Fatalf("Run returned %v after a failed restart", err)
+		default:
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Fatalf("Run error = %v; want nil", err)
+		}
+	})
+}
+
+func TestServicesRunner_Restart_Errors(t *testing.T) {
+	ln := newTestLauncher()
+	runner := ln.ServicesRunner()
+
+	if err := runner.Restart(t.Context(), "missing"); !errors.Is(err, launcher.ErrServiceNotFound) {
+		t.Errorf("Restart(missing) error = %v; want ErrServiceNotFound", err)
+	}
+
+	runner.Register(launcher.NewService(
+		launcher.WithServiceName("idle"),
+		launcher.WithStart(blockingStart),
+		launcher.WithStop(noopStop),
+	))
+	if err := runner.Restart(t.Context(), "idle"); !errors.Is(err, launcher.ErrNotRunning) {
+		t.Errorf("Restart before Run error = %v; want ErrNotRunning", err)
+	}
+}
@@ -0,0 +1,116 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"strings"
+)
+
+// ErrForcedShutdown is returned by Run, wrapped in a *ForcedShutdownError,
+// when the graceful shutdown was forced and the exit func returned instead of
+// exiting the process.
+var ErrForcedShutdown = errors.New("forced shutdown")
+
+// ForcedShutdownError describes a forced shutdown.
+type ForcedShutdownError struct {
+	// Reason is why the shutdown was forced: a second shutdown signal or the
+	// global shutdown timeout.
+	Reason string
+	// Phase is the phase the shutdown was forced in, always PhaseStop.
+	Phase Phase
+	// Pending holds a stop error for each service that was still running or
+	// stopping, with the attempt that was running and an error wrapping
+	// ErrForcedShutdown.
+	Pending []StopError
+	// Stacks holds the goroutine stacks of the pending services.
+	Stacks string
+}
+
+func (e *ForcedShutdownError) Error() string {
+	return fmt.Sprintf("%s: %s, services still stopping: [%s]", ErrForcedShutdown, e.Reason, strings.Join(e.pendingNames(), ", "))
+}
+
+// pendingNames returns the names of the pending services.
+func (e *ForcedShutdownError) pendingNames() []string {
+	names := make([]string, len(e.Pending))
+	for i, p := range e.Pending {
+		names[i] = p.Service
+	}
+	return names
+}
+
+func (e *ForcedShutdownError) Unwrap() error { return ErrForcedShutdown }
+
+// serviceLabel is the profiler label naming the service a goroutine runs for.
+const serviceLabel = "mx_service"
+
+// withServiceLabel runs fn with the profiler label of the named service, so
+// its goroutines can be told apart in a stack dump.
+func withServiceLabel(ctx context.Context, name string, fn func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(serviceLabel, name), fn)
+}
+
+// forceShutdown logs the services still stopping together with their stacks
+// and calls the exit func. If the exit func returns, the error is sent to
+// forced. Only the first forced shutdown has an effect.
+func (l *launcher) forceShutdown(reason string, forced chan<- error) {
+	if !l.forced.CompareAndSwap(false, true) {
+		return
+	}
+
+	err := &ForcedShutdownError{Reason: reason, Phase: PhaseStop}
+	for _, svc := range l.servicesRunner.Services() {
+		switch state := svc.State(); state {
+		case ServiceStateStarting, ServiceStateRunning, ServiceStateStopping:
+			err.Pending = append(err.Pending, StopError{
+				Service: svc.Name(),
+				Phase:   PhaseStop,
+				Attempt: int(svc.attempt.Load()),
+				Err:     fmt.Errorf("%w while %s", ErrForcedShutdown, state),
+			})
+		}
+	}
+
+	pending := err.pendingNames()
+	err.Stacks = serviceStacks(pending)
+
+	l.opts.logger.Errorf("%s, forcing exit; services still stopping: [%s]", reason, strings.Join(pending, ", "))
+	if err.Stacks != "" {
+		l.opts.logger.Errorf("goroutines of the services still stopping:\n%s", err.Stacks)
+	}
+
+	l.opts.ExitFunc(1)
+
+	select {
+	case forced <- err:
+	default:
+	}
+}
+
+// serviceStacks returns the goroutine stacks labeled with one of the given
+// services, as in the debug=1 goroutine profile.
+func serviceStacks(services []string) string {
+	if len(services) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return ""
+	}
+
+	var stacks []string
+	for record := range strings.SplitSeq(buf.String(), "\n\n") {
+		for _, name := range services {
+			if strings.Contains(record, fmt.Sprintf("%q:%q", serviceLabel, name)) {
+				stacks = append(stacks, strings.TrimSpace(record))
+				break
+			}
+		}
+	}
+
+	return strings.Join(stacks, "\n\n")
+}
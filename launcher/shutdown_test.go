@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+)
+
+// stuckLauncher returns a launcher with a service whose Stop blocks until the
+// test ends, and a channel receiving the exit codes passed to the exit func.
+func stuckLauncher(t *testing.T, opts ...Option) (ILauncher, <-chan int) {
+	t.Helper()
+
+	exits := make(chan int, 1)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	ln := New(append([]Option{
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithExitFunc(func(code int) { exits <- code }),
+	}, opts...)...)
+	ln.ServicesRunner().Register(
+		NewService(
+			WithServiceName("stuck"),
+			WithShutdownTimeout(time.Hour),
+			WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+			WithStop(func(context.Context) error { <-release; return nil }),
+		),
+		NewService(
+			WithServiceName("quick"),
+			WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+			WithStop(func(context.Context) error { return nil }),
+		),
+	)
+	return ln, exits
+}
+
+func assertForcedShutdown(t *testing.T, err error, reason string) {
+	t.Helper()
+
+	if !errors.Is(err, ErrForcedShutdown) {
+		t.Fatalf("Run error = %v; want ErrForcedShutdown", err)
+	}
+	var fse *ForcedShutdownError
+	if !errors.As(err, &fse) {
+		t.Fatalf("Run error = %T; want *ForcedShutdownError", err)
+	}
+	if fse.Reason != reason {
+		t.Errorf("Reason = %q; want %q", fse.Reason, reason)
+	}
+	if fse.Phase != PhaseStop {
+		t.Errorf("Phase = %q; want stop", fse.Phase)
+	}
+	if len(fse.Pending) != 1 || fse.Pending[0].Service != "stuck" || fse.Pending[0].Phase != PhaseStop ||
+		fse.Pending[0].Attempt != 1 || !errors.Is(&fse.Pending[0], ErrForcedShutdown) {
+		t.Errorf("Pending = %+v; want the stop of stuck", fse.Pending)
+	}
+	if !strings.Contains(fse.Stacks, "stuckLauncher") {
+		t.Errorf("Stacks do not include the stuck Stop func:\n%s", fse.Stacks)
+	}
+}
+
+func TestLauncher_ForcedShutdown_GlobalTimeout(t *testing.T) {
+	ln, exits := stuckLauncher(t, WithSignal(false), WithGlobalShutdownTimeout(100*time.Millisecond))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	time.Sleep(50 * time.Millisecond)
+	ln.Stop()
+
+	select {
+	case err := <-errCh:
+		assertForcedShutdown(t, err, "global shutdown timeout exceeded")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the global shutdown timeout")
+	}
+
+	if code := <-exits; code != 1 {
+		t.Errorf("exit code = %d; want 1", code)
+	}
+}
+
+func TestLauncher_ForcedShutdown_SecondSignal(t *testing.T) {
+	sendSignal := fakeSignals(t)
+	ln, exits := stuckLauncher(t)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	sendSignal(syscall.SIGTERM)
+	stuck, _ := ln.ServicesRunner().Get("stuck")
+	for stuck.State() != ServiceStateStopping {
+		time.Sleep(time.Millisecond)
+	}
+	sendSignal(syscall.SIGINT)
+
+	select {
+	case err := <-errCh:
+		assertForcedShutdown(t, err, "received second signal")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the second signal")
+	}
+
+	if code := <-exits; code != 1 {
+		t.Errorf("exit code = %d; want 1", code)
+	}
+}
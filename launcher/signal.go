@@ -1,13 +1,75 @@
 package launcher
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"syscall"
 )
 
+// notifySignals is signal.Notify, replaced in tests.
+var notifySignals = signal.Notify
+
 // ShutdownSiganl returns all the signals that are being watched for to shut down services.
 func ShutdownSiganl() []os.Signal {
 	return []os.Signal{
 		syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT,
 	}
 }
+
+// watchedSignals returns the signals the launcher listens to.
+func (l *launcher) watchedSignals() []os.Signal {
+	sigs := slices.Clone(l.opts.ShutdownSignals)
+	sigs = append(sigs, l.opts.ReloadSignals...)
+	for sig := range l.opts.SignalHandlers {
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
+
+func (l *launcher) isShutdownSignal(sig os.Signal) bool {
+	return slices.Contains(l.opts.ShutdownSignals, sig)
+}
+
+// handleSignal reloads the services on a reload signal and calls the handlers
+// registered for sig. Signals are handled one at a time. A panic in a handler
+// is handled by the PanicPolicy and does not skip the other handlers.
+func (l *launcher) handleSignal(sig os.Signal) {
+	l.signalMu.Lock()
+	defer l.signalMu.Unlock()
+
+	if slices.Contains(l.opts.ReloadSignals, sig) {
+		l.opts.logger.Infof("received signal %s, reloading services", sig)
+		if err := l.reload(l.opts.Context); err != nil {
+			l.opts.logger.Errorf("failed to reload services: %s", err)
+		}
+	}
+
+	for _, fn := range l.opts.SignalHandlers[sig] {
+		// a recovered panic is logged by call
+		_ = l.opts.PanicPolicy.call(l.opts.logger, fmt.Sprintf("handler of signal %s", sig), func() error {
+			fn(sig)
+			return nil
+		})
+	}
+}
+
+// reload reloads every running service supporting it, in registration order.
+func (l *launcher) reload(ctx context.Context) error {
+	var errs error
+	for _, svc := range l.servicesRunner.Services() {
+		if svc.Options().ReloadFn == nil || svc.State() != ServiceStateRunning {
+			continue
+		}
+
+		if err := svc.Reload(ctx); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("service [%s]: %w", svc.Name(), err))
+			continue
+		}
+		l.opts.logger.Infof("service [%s] was reloaded", svc.Name())
+	}
+	return errs
+}
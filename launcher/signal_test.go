@@ -0,0 +1,213 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tkcrm/mx/logger"
+)
+
+func TestLauncher_ReloadSignal(t *testing.T) {
+	sendSignal := fakeSignals(t)
+
+	reloadErr := errors.New("bad config")
+	reloads := make(chan struct{}, 2)
+	events := make(chan LifecycleEvent, 2)
+
+	ln := New(
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithReloadSignals(syscall.SIGHUP),
+		WithStateChangeHandler(func(ev LifecycleEvent) {
+			if ev.Reason == EventReasonReload {
+				events <- ev
+			}
+		}),
+	)
+
+	calls := 0
+	ln.ServicesRunner().Register(
+		NewService(
+			WithServiceName("api"),
+			WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+			WithStop(func(context.Context) error { return nil }),
+			WithReload(func(context.Context) error {
+				defer func() { reloads <- struct{}{} }()
+				calls++
+				if calls == 2 {
+					return reloadErr
+				}
+				return nil
+			}),
+		),
+		// services without a reload function are skipped
+		NewService(
+			WithServiceName("worker"),
+			WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+			WithStop(func(context.Context) error { return nil }),
+		),
+	)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	// only running services are reloaded
+	api, _ := ln.ServicesRunner().Get("api")
+	select {
+	case <-api.Ready():
+	case <-time.After(10 * time.Second):
+		t.Fatal("api did not start in time")
+	}
+
+	for i, wantErr := range []error{nil, reloadErr} {
+		sendSignal(syscall.SIGHUP)
+
+		select {
+		case <-reloads:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("reload %d was not called", i+1)
+		}
+
+		ev := <-events
+		if ev.Service != "api" || ev.From != ServiceStateRunning || ev.To != ServiceStateRunning {
+			t.Errorf("reload event = %+v; want api staying running", ev)
+		}
+		if !errors.Is(ev.Err, wantErr) {
+			t.Errorf("reload %d event error = %v; want %v", i+1, ev.Err, wantErr)
+		}
+	}
+
+	// the launcher keeps running after a failed reload
+	select {
+	case err := <-errCh:
+		t.Fatalf("Run returned after a reload: %v", err)
+	default:
+	}
+
+	sendSignal(syscall.SIGTERM)
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+}
+
+func TestLauncher_CustomSignals(t *testing.T) {
+	sendSignal := fakeSignals(t)
+
+	handled := make(chan os.Signal, 1)
+	ln := New(
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithShutdownSignals(syscall.SIGTERM),
+		WithSignalHandler(syscall.SIGINT, func(sig os.Signal) { handled <- sig }),
+	)
+	ln.ServicesRunner().Register(NewService(
+		WithServiceName("api"),
+		WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+		WithStop(func(context.Context) error { return nil }),
+	))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	// SIGINT no longer shuts down, it is passed to the handler
+	sendSignal(syscall.SIGINT)
+	select {
+	case sig := <-handled:
+		if sig != syscall.SIGINT {
+			t.Errorf("handler got %s; want SIGINT", sig)
+		}
+	case err := <-errCh:
+		t.Fatalf("Run returned on SIGINT: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("signal handler was not called")
+	}
+
+	sendSignal(syscall.SIGTERM)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return on SIGTERM")
+	}
+}
+
+// Panics in reload funcs and signal handlers are recovered and the launcher
+// keeps running.
+func TestLauncher_SignalPanics(t *testing.T) {
+	sendSignal := fakeSignals(t)
+
+	handled := make(chan os.Signal, 1)
+	events := make(chan LifecycleEvent, 1)
+
+	ln := New(
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithReloadSignals(syscall.SIGHUP),
+		WithSignalHandler(syscall.SIGHUP, func(os.Signal) { panic("handler bug") }),
+		WithSignalHandler(syscall.SIGHUP, func(sig os.Signal) { handled <- sig }),
+		WithStateChangeHandler(func(ev LifecycleEvent) {
+			if ev.Reason == EventReasonReload {
+				events <- ev
+			}
+		}),
+	)
+	ln.ServicesRunner().Register(NewService(
+		WithServiceName("api"),
+		WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+		WithStop(func(context.Context) error { return nil }),
+		WithReload(func(context.Context) error { panic("reload bug") }),
+	))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	api, _ := ln.ServicesRunner().Get("api")
+	select {
+	case <-api.Ready():
+	case <-time.After(10 * time.Second):
+		t.Fatal("api did not start in time")
+	}
+
+	sendSignal(syscall.SIGHUP)
+
+	select {
+	case ev := <-events:
+		var panicErr *PanicError
+		if !errors.As(ev.Err, &panicErr) || panicErr.Value != "reload bug" {
+			t.Errorf("reload event error = %v; want the recovered panic", ev.Err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("reload was not reported")
+	}
+
+	// the handler after the panicking one still runs
+	select {
+	case <-handled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("signal handler was not called after a panicking one")
+	}
+
+	sendSignal(syscall.SIGTERM)
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+}
+
+func TestLauncher_WatchedSignals(t *testing.T) {
+	l := New(
+		WithShutdownSignals(syscall.SIGTERM),
+		WithReloadSignals(syscall.SIGHUP),
+		WithSignalHandler(syscall.SIGINT, func(os.Signal) {}),
+	).(*launcher)
+
+	got := l.watchedSignals()
+	if len(got) != 3 {
+		t.Fatalf("watchedSignals = %v; want SIGTERM, SIGHUP and SIGINT", got)
+	}
+	if !l.isShutdownSignal(syscall.SIGTERM) || l.isShutdownSignal(syscall.SIGINT) {
+		t.Error("only SIGTERM must be a shutdown signal")
+	}
+}
@@ -0,0 +1,129 @@
+package launcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tkcrm/mx/launcher/ops"
+)
+
+// startupTracker follows the startup of the services for the ops startup
+// probe. It is fed by the launcher event bus. All methods of the launcher side
+// are no-ops on a nil tracker.
+type startupTracker struct {
+	runner *servicesRunner
+
+	mu sync.Mutex
+	// launched holds when each service was handed to the launcher to be
+	// started and ready how long it then took to become ready.
+	launched map[string]time.Time
+	ready    map[string]time.Duration
+	// groupsLaunched is set once every startup-priority group was launched.
+	groupsLaunched bool
+	// complete latches the first successful startup.
+	complete bool
+}
+
+func newStartupTracker(runner *servicesRunner) *startupTracker {
+	return &startupTracker{
+		runner:   runner,
+		launched: make(map[string]time.Time),
+		ready:    make(map[string]time.Duration),
+	}
+}
+
+// init marks the services registered before Run as launched.
+func (t *startupTracker) init(services []*Service) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for _, svc := range services {
+		t.launched[svc.Name()] = now
+	}
+}
+
+// launch marks the moment svc is handed to the launcher to be started.
+func (t *startupTracker) launch(svc *Service) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.launched[svc.Name()] = time.Now()
+	delete(t.ready, svc.Name())
+}
+
+// launchedAll marks that every startup-priority group was launched.
+func (t *startupTracker) launchedAll() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.groupsLaunched = true
+}
+
+// observe records the time to ready of a service on its first transition to
+// Running after a launch.
+func (t *startupTracker) observe(ev LifecycleEvent) {
+	if ev.To != ServiceStateRunning {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.ready[ev.Service]; ok {
+		return
+	}
+	if at, ok := t.launched[ev.Service]; ok {
+		t.ready[ev.Service] = ev.Time.Sub(at)
+	}
+}
+
+// StartupComplete implements ops.StartupTracker.
+func (t *startupTracker) StartupComplete() bool {
+	services := t.runner.Services()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.complete {
+		return true
+	}
+	if !t.groupsLaunched {
+		return false
+	}
+
+	for _, svc := range services {
+		if svc.internal {
+			continue
+		}
+		if _, ok := t.ready[svc.Name()]; !ok {
+			return false
+		}
+	}
+
+	t.complete = true
+	return true
+}
+
+// TimeToReady implements ops.StartupTracker.
+func (t *startupTracker) TimeToReady(name string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d, ok := t.ready[name]
+	return d, ok
+}
+
+var _ ops.StartupTracker = (*startupTracker)(nil)
@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tkcrm/mx/launcher/ops"
+	"github.com/tkcrm/mx/logger"
+)
+
+func TestStartupTracker(t *testing.T) {
+	runner := newServicesRunner(context.Background(), logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal)))
+	newSvc := func(name string) *Service {
+		return NewService(
+			WithServiceName(name),
+			WithStart(func(context.Context) error { return nil }),
+			WithStop(func(context.Context) error { return nil }),
+		)
+	}
+	db, api, internal := newSvc("db"), newSvc("api"), newSvc("ops")
+	internal.internal = true
+	runner.Register(db, api, internal)
+
+	tr := newStartupTracker(runner)
+	tr.init(runner.Services())
+
+	now := time.Now()
+	tr.observe(LifecycleEvent{Service: "db", From: ServiceStateStarting, To: ServiceStateRunning, Time: now.Add(time.Hour)})
+	if tr.StartupComplete() {
+		t.Fatal("StartupComplete = true before the groups were launched")
+	}
+
+	tr.launchedAll()
+	if tr.StartupComplete() {
+		t.Fatal("StartupComplete = true while api is not ready")
+	}
+	if _, ok := tr.TimeToReady("api"); ok {
+		t.Error("api has a time to ready before it is ready")
+	}
+
+	// api is launched later, e.g. after its dependencies
+	tr.launch(api)
+	tr.observe(LifecycleEvent{Service: "api", From: ServiceStateStarting, To: ServiceStateRunning, Time: time.Now().Add(2 * time.Second)})
+	if !tr.StartupComplete() {
+		t.Fatal("StartupComplete = false; want true once every service is ready")
+	}
+
+	if d, ok := tr.TimeToReady("db"); !ok || d < time.Hour {
+		t.Errorf("db time to ready = %v, %v; want at least 1h", d, ok)
+	}
+	if d, ok := tr.TimeToReady("api"); !ok || d < 2*time.Second || d > time.Hour {
+		t.Errorf("api time to ready = %v, %v; want about 2s", d, ok)
+	}
+
+	// a restart neither resets the time to ready nor the completed startup
+	tr.observe(LifecycleEvent{Service: "db", From: ServiceStateRunning, To: ServiceStateStarting, Reason: EventReasonRestartPolicy, Time: now.Add(2 * time.Hour)})
+	tr.observe(LifecycleEvent{Service: "db", From: ServiceStateStarting, To: ServiceStateRunning, Time: now.Add(3 * time.Hour)})
+	if d, _ := tr.TimeToReady("db"); d >= 2*time.Hour {
+		t.Errorf("db time to ready = %v after a restart; want the first one", d)
+	}
+	if !tr.StartupComplete() {
+		t.Error("StartupComplete = false after a restart; want true")
+	}
+}
+
+func TestStartupTracker_Nil(t *testing.T) {
+	var tr *startupTracker
+	tr.init([]*Service{NewService(WithServiceName("svc"))})
+	tr.launch(NewService(WithServiceName("svc")))
+	tr.launchedAll()
+}
+
+// The launcher reports its startup progress to the ops health checker when it
+// is enabled; the startup completes once readiness-gated services are ready.
+func TestLauncher_Startup(t *testing.T) {
+	ready := make(chan struct{})
+	started := make(chan struct{})
+
+	ln := New(
+		WithSignal(false),
+		WithLogger(logger.NewExtended(logger.WithLogLevel(logger.LogLevelFatal))),
+		WithAfterStart(func() error { close(started); return nil }),
+		WithOpsConfig(ops.Config{
+			Enabled: true,
+			Network: "tcp",
+			Healthy: ops.HealthCheckerConfig{Enabled: true, Path: "/healthy", Port: "0"},
+		}),
+	)
+	ln.ServicesRunner().Register(NewService(
+		WithServiceName("worker"),
+		WithStart(func(ctx context.Context) error { <-ctx.Done(); return nil }),
+		WithStop(func(context.Context) error { return nil }),
+		WithReadiness(ready),
+	))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	select {
+	case <-started:
+	case err := <-errCh:
+		t.Fatalf("Run returned before startup completed: %v", err)
+	case <-time.After(10 * time.Second):
+		ln.Stop()
+		t.Fatal("services did not start in time")
+	}
+
+	tr := ln.(*launcher).startup
+	if tr.StartupComplete() {
+		t.Error("StartupComplete = true before worker is ready")
+	}
+
+	close(ready)
+	deadline := time.Now().Add(10 * time.Second)
+	for !tr.StartupComplete() {
+		if time.Now().After(deadline) {
+			t.Fatal("startup did not complete in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := tr.TimeToReady("worker"); !ok {
+		t.Error("worker has no time to ready")
+	}
+
+	ln.Stop()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/tkcrm/mx/launcher"
+	"github.com/tkcrm/mx/launcher/ops"
+)
+
+// unhealthyService is a service with a health checker whose start and stop
+// calls are counted.
+type unhealthyService struct {
+	mu     sync.Mutex
+	starts int
+	stops  int
+}
+
+func (s *unhealthyService) Name() string { return "api" }
+
+func (s *unhealthyService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.starts++
+	s.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (s *unhealthyService) Stop(context.Context) error {
+	s.mu.Lock()
+	s.stops++
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *unhealthyService) Interval() time.Duration       { return time.Second }
+func (s *unhealthyService) Healthy(context.Context) error { return nil }
+
+func (s *unhealthyService) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.starts, s.stops
+}
+
+// runUnhealthy runs a launcher with impl restarted by policy and returns the
+// launcher, the service, the Run error channel and the recorded lifecycle
+// events.
+func runUnhealthy(impl *unhealthyService, policy launcher.RestartPolicy, opts ...launcher.ServiceOption) (launcher.ILauncher, *launcher.Service, <-chan error, func() []launcher.LifecycleEvent) {
+	var (
+		mu     sync.Mutex
+		events []launcher.LifecycleEvent
+	)
+
+	ln := newTestLauncher(
+		launcher.WithLogger(quietExtended()),
+		launcher.WithStateChangeHandler(func(ev launcher.LifecycleEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}),
+	)
+	svc := launcher.NewService(append([]launcher.ServiceOption{launcher.WithService(impl), launcher.WithRestartPolicy(policy)}, opts...)...)
+	ln.ServicesRunner().Register(svc)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ln.Run() }()
+
+	return ln, svc, errCh, func() []launcher.LifecycleEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return events
+	}
+}
+
+func TestLauncher_RestartOnUnhealthy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		impl := &unhealthyService{}
+		ln, svc, errCh, events := runUnhealthy(impl, launcher.RestartPolicy{
+			Mode:               launcher.RestartOnFailure,
+			Delay:              time.Second,
+			UnhealthyThreshold: 3,
+		})
+		<-svc.Ready()
+
+		// the ops health checker reports the status of every poll to the launcher
+		observer := ln.(ops.HealthStatusObserver)
+
+		failure := errors.New("pool exhausted")
+
+		// a success in between resets the consecutive failures
+		observer.ObserveHealthStatus("api", failure)
+		observer.ObserveHealthStatus("api", failure)
+		observer.ObserveHealthStatus("api", nil)
+		observer.ObserveHealthStatus("api", failure)
+		observer.ObserveHealthStatus("api", failure)
+		synctest.Wait()
+		if svc.Restarts() != 0 {
+			t.Fatalf("restarts = %d; want none below the threshold", svc.Restarts())
+		}
+
+		observer.ObserveHealthStatus("api", failure)
+		time.Sleep(2 * time.Second)
+		synctest.Wait()
+
+		if starts, stops := impl.counts(); starts != 2 || stops != 1 {
+			t.Errorf("starts = %d, stops = %d; want the service stopped and started again", starts, stops)
+		}
+		if svc.Restarts() != 1 || svc.State() != launcher.ServiceStateRunning {
+			t.Errorf("restarts = %d, state = %s; want 1 restart, running", svc.Restarts(), svc.State())
+		}
+
+		var restarted bool
+		for _, ev := range events() {
+			if ev.To == launcher.ServiceStateStarting && ev.Reason == launcher.EventReasonUnhealthy {
+				restarted = true
+				if !errors.Is(ev.Err, launcher.ErrUnhealthy) || !errors.Is(ev.Err, failure) {
+					t.Errorf("event error = %v; want ErrUnhealthy with the health check error", ev.Err)
+				}
+			}
+		}
+		if !restarted {
+			t.Errorf("events = %+v; want a restart with reason unhealthy", events())
+		}
+
+		// checks of other services do not count
+		for range 3 {
+			observer.ObserveHealthStatus("other", failure)
+		}
+		synctest.Wait()
+		if svc.Restarts() != 1 {
+			t.Errorf("restarts = %d; want failures of other checks ignored", svc.Restarts())
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Errorf("Run error = %v; want nil", err)
+		}
+	})
+}
+
+// The stop of an unhealthy service runs its stop hooks, e.g. to deregister
+// it from discovery before it is restarted.
+func TestLauncher_RestartOnUnhealthy_StopHooks(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var (
+			mu    sync.Mutex
+			calls []string
+		)
+		record := func(name string) func() error {
+			return func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, name)
+				return nil
+			}
+		}
+
+		impl := &unhealthyService{}
+		ln, svc, errCh, _ := runUnhealthy(impl,
+			launcher.RestartPolicy{Mode: launcher.RestartOnFailure, Delay: time.Second, UnhealthyThreshold: 1},
+			launcher.WithServiceBeforeStop(record("before_stop")),
+			launcher.WithServiceAfterStopHook(launcher.Hook{Name: "flush", Fn: func(context.Context) error { return record("after_stop")() }}),
+		)
+		<-svc.Ready()
+
+		ln.(ops.HealthStatusObserver).ObserveHealthStatus("api", errors.New("pool exhausted"))
+		time.Sleep(2 * time.Second)
+		synctest.Wait()
+
+		if svc.Restarts() != 1 {
+			t.Fatalf("restarts = %d; want the unhealthy service restarted", svc.Restarts())
+		}
+		mu.Lock()
+		got := slices.Clone(calls)
+		mu.Unlock()
+		if !slices.Equal(got, []string{"before_stop", "after_stop"}) {
+			t.Errorf("hooks = %v; want the stop hooks run once around the stop", got)
+		}
+
+		ln.Stop()
+		if err := <-errCh; err != nil {
+			t.Errorf("Run error = %v; want nil", err)
+		}
+	})
+}
+
+func TestLauncher_RestartOnUnhealthy_RestartNever(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		impl := &unhealthyService{}
+		ln, svc, errCh, _ := runUnhealthy(impl, launcher.RestartPolicy{UnhealthyThreshold: 1})
+		<-svc.Ready()
+
+		ln.(ops.HealthStatusObserver).ObserveHealthStatus("api", errors.New("deadlocked"))
+
+		err := <-errCh
+		if !errors.Is(err, launcher.ErrUnhealthy) {
+			t.Fatalf("Run error = %v; want ErrUnhealthy", err)
+		}
+		if svc.State() != launcher.ServiceStateFailed {
+			t.Errorf("state = %s; want failed", svc.State())
+		}
+	})
+}
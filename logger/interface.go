@@ -27,6 +27,10 @@ type ExtendedLogger interface {
 	Sync() error
 	Std() *log.Logger
 	Sugar() *sugaredLogger
+	// Level returns the current log level.
+	Level() LogLevel
+	// SetLevel changes the log level at runtime.
+	SetLevel(level LogLevel) error
 }
 
 // Logger common interface.
@@ -32,6 +32,24 @@ const (
 	LogLevelPanic LogLevel = "panic"
 )
 
+// fromZapLevel converts zap level into log level.
+func fromZapLevel(level zapcore.Level) LogLevel {
+	switch level {
+	case zapcore.DebugLevel:
+		return LogLevelDebug
+	case zapcore.WarnLevel:
+		return LogLevelWarn
+	case zapcore.ErrorLevel:
+		return LogLevelError
+	case zapcore.PanicLevel, zapcore.DPanicLevel:
+		return LogLevelPanic
+	case zapcore.FatalLevel:
+		return LogLevelFatal
+	default:
+		return LogLevelInfo
+	}
+}
+
 // safeLevel converts string representation into log level.
 func safeLevel(level LogLevel) zapcore.Level {
 	switch level {
@@ -2,8 +2,10 @@
 package logger
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,6 +20,10 @@ type logger struct {
 	zapConfig zapcore.EncoderConfig
 	options   []zap.Option
 
+	// level is shared by the loggers derived with With, so a change of the
+	// level applies to all of them.
+	level zap.AtomicLevel
+
 	*sugaredLogger
 }
 
@@ -27,6 +33,21 @@ func (l *logger) Sugar() *sugaredLogger { return l.sugaredLogger }
 // Std returns standard library log.Logger.
 func (l *logger) Std() *log.Logger { return zap.NewStdLog(l.Desugar()) }
 
+// Level returns the current log level.
+func (l *logger) Level() LogLevel { return fromZapLevel(l.level.Level()) }
+
+// SetLevel changes the log level at runtime.
+func (l *logger) SetLevel(level LogLevel) error {
+	level = LogLevel(strings.ToLower(level.String()))
+	if !level.Valid() {
+		return fmt.Errorf("invalid logger level: %s", level)
+	}
+
+	l.level.SetLevel(safeLevel(level))
+
+	return nil
+}
+
 // Logger returns logger instance.
 func (l *logger) LoggerInstance() *logger {
 	return l
@@ -56,7 +77,7 @@ func DefaultExtended() ExtendedLogger {
 		zap.AddCaller(),
 	)
 
-	return &logger{sugaredLogger: l.Sugar()}
+	return &logger{level: atom, sugaredLogger: l.Sugar()}
 }
 
 // New - init new logger with options.
@@ -84,6 +105,7 @@ func With(l Logger, args ...any) Logger {
 		lg.appVersion,
 		lg.zapConfig,
 		lg.options,
+		lg.level,
 		lg.With(args...),
 	}
 }
@@ -103,6 +125,7 @@ func WithExtended(l ExtendedLogger, args ...any) ExtendedLogger {
 		lg.appVersion,
 		lg.zapConfig,
 		lg.options,
+		lg.level,
 		lg.With(args...),
 	}
 }
@@ -137,14 +160,13 @@ func initLogger(opts ...Option) *logger {
 		buildOpts = append(buildOpts, zap.AddStacktrace(logTrace))
 	}
 
-	atom := zap.NewAtomicLevel()
-	atom.SetLevel(logLevel)
+	l.level = zap.NewAtomicLevelAt(logLevel)
 
 	zapLogger := zap.New(
 		zapcore.NewCore(
 			encoder,
 			zapcore.Lock(os.Stdout),
-			atom,
+			l.level,
 		),
 		buildOpts...,
 	)
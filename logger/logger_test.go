@@ -39,3 +39,29 @@ func Test_LoggerWith(t *testing.T) {
 
 	l.Infow("some test value", "numbers", 1234)
 }
+
+func TestLogger_SetLevel(t *testing.T) {
+	l := logger.NewExtended(logger.WithLogLevel(logger.LogLevelInfo))
+	child := logger.WithExtended(l, "key", "value")
+
+	if l.Level() != logger.LogLevelInfo {
+		t.Fatalf("Level = %s; want info", l.Level())
+	}
+
+	if err := l.SetLevel("DEBUG"); err != nil {
+		t.Fatalf("SetLevel error: %v", err)
+	}
+	if l.Level() != logger.LogLevelDebug || child.Level() != logger.LogLevelDebug {
+		t.Errorf("levels = %s, %s; want debug for the derived logger too", l.Level(), child.Level())
+	}
+	if !l.Sugar().Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("debug is not enabled after SetLevel")
+	}
+
+	if err := l.SetLevel("verbose"); err == nil {
+		t.Error("SetLevel(verbose) error = nil; want invalid level")
+	}
+	if l.Level() != logger.LogLevelDebug {
+		t.Errorf("Level = %s; want debug kept after an invalid level", l.Level())
+	}
+}
@@ -1,6 +1,10 @@
 package logger
 
-import "go.uber.org/zap/zaptest"
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
 
 type testingT interface {
 	Helper()
@@ -10,5 +14,6 @@ type testingT interface {
 // ForTests wrapped logger for tests.
 func ForTests(t testingT) Logger { //nolint:ireturn
 	t.Helper()
-	return &logger{sugaredLogger: zaptest.NewLogger(t).Sugar()}
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	return &logger{level: level, sugaredLogger: zaptest.NewLogger(t, zaptest.Level(level)).Sugar()}
 }
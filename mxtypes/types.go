@@ -20,6 +20,46 @@ type HealthChecker interface {
 	Healthy(ctx context.Context) error
 }
 
+// HealthDetailer is an optional interface a HealthChecker can implement to
+// attach details, such as connection pool statistics, to its health check
+// result. HealthDetails is called after every check; the details are shown
+// only in the verbose output of the ops health probes.
+type HealthDetailer interface {
+	HealthDetails() map[string]any
+}
+
+// HealthCheckPolicy tunes how the ops health checker runs a check and
+// interprets its results. Zero fields fall back to the ops config defaults.
+type HealthCheckPolicy struct {
+	// Timeout bounds a single Healthy call; a call that does not return in
+	// time counts as a failure.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failures after which a
+	// healthy check is reported unhealthy.
+	FailureThreshold int
+	// SuccessThreshold is the number of consecutive successes after which an
+	// unhealthy check is reported healthy again.
+	SuccessThreshold int
+}
+
+// HealthCheckPolicyProvider is an optional interface a HealthChecker can
+// implement to override the health check policy of the ops config.
+type HealthCheckPolicyProvider interface {
+	HealthCheckPolicy() HealthCheckPolicy
+}
+
+// HealthCheckClassifier is an optional interface a HealthChecker can implement
+// to classify its check for the ops readiness probes.
+type HealthCheckClassifier interface {
+	// HealthCheckCritical reports whether a failing check makes the app not
+	// ready. A failing non-critical check only marks readiness degraded.
+	// Checkers that do not implement this interface are critical.
+	HealthCheckCritical() bool
+	// HealthCheckTags groups the check, e.g. "db", "cache" or "external", so a
+	// probe can evaluate a subset of checks with ?tag=.
+	HealthCheckTags() []string
+}
+
 // Enabler is the interface that provides enabled state of a service.
 type Enabler interface {
 	Enabled() bool
@@ -47,6 +87,22 @@ type ReadinessReporter interface {
 	Ready() <-chan struct{}
 }
 
+// Reloader is an optional interface a service can implement to reload its
+// configuration, certificates or other resources without a restart. The
+// launcher calls Reload on every running service implementing it when a reload
+// signal arrives.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+// InFlightReporter is an optional interface a service serving requests, such
+// as an HTTP or gRPC server, can implement to report how many requests it is
+// handling. During a graceful drain the launcher can wait for the sum of all
+// in-flight requests to reach zero before it stops the services.
+type InFlightReporter interface {
+	InFlight() int64
+}
+
 // ServiceState represents the current lifecycle state of a service.
 type ServiceState int
 
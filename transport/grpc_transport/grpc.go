@@ -3,6 +3,7 @@ package grpc_transport
 import (
 	"context"
 	"net"
+	"sync/atomic"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
@@ -26,13 +27,16 @@ type GRPCServer struct {
 	server   *grpc.Server
 	logger   logger.Logger
 	services []GRPCService
+
+	inFlight *atomic.Int64
 }
 
 // NewServer creates a new gRPC server that implements service.IService interface.
 func NewServer(opts ...Option) *GRPCServer {
 	srv := &GRPCServer{
-		name:   defaultGRPCName,
-		logger: logger.Default(),
+		name:     defaultGRPCName,
+		logger:   logger.Default(),
+		inFlight: new(atomic.Int64),
 
 		Config: Config{
 			Enabled: true,
@@ -49,10 +53,14 @@ func NewServer(opts ...Option) *GRPCServer {
 
 	if srv.server == nil {
 		// define unary interceptors
-		unaryInterceptors := []grpc.UnaryServerInterceptor{}
+		unaryInterceptors := []grpc.UnaryServerInterceptor{
+			inFlightUnaryInterceptor(srv.inFlight),
+		}
 
 		// define stream interceptors
-		streamInterceptors := []grpc.StreamServerInterceptor{}
+		streamInterceptors := []grpc.StreamServerInterceptor{
+			inFlightStreamInterceptor(srv.inFlight),
+		}
 
 		// add logger
 		if srv.LoggerEnabled {
@@ -114,6 +122,11 @@ func (s *GRPCServer) Name() string { return s.name }
 // Enabled returns is service enabled.
 func (s *GRPCServer) Enabled() bool { return s.Config.Enabled }
 
+// InFlight returns the number of RPCs being handled. It implements
+// mxtypes.InFlightReporter, so the launcher can wait for them while draining.
+// RPCs of a server set with WithServer are not counted.
+func (s *GRPCServer) InFlight() int64 { return s.inFlight.Load() }
+
 // Start allows starting gRPC server.
 func (s *GRPCServer) Start(ctx context.Context) error {
 	s.logger.Infof(
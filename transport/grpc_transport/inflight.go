@@ -0,0 +1,26 @@
+package grpc_transport
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// inFlightUnaryInterceptor counts the unary RPCs being handled.
+func inFlightUnaryInterceptor(counter *atomic.Int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		counter.Add(1)
+		defer counter.Add(-1)
+		return handler(ctx, req)
+	}
+}
+
+// inFlightStreamInterceptor counts the streaming RPCs being handled.
+func inFlightStreamInterceptor(counter *atomic.Int64) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		counter.Add(1)
+		defer counter.Add(-1)
+		return handler(srv, ss)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/tkcrm/mx/logger"
@@ -17,6 +18,8 @@ type HTTPServer struct {
 	handle http.Handler
 	server *http.Server
 	logger logger.ExtendedLogger
+
+	inFlight *atomic.Int64
 }
 
 const defaultHTTPName = "http-server"
@@ -24,8 +27,9 @@ const defaultHTTPName = "http-server"
 // NewServer creates http server.
 func NewServer(opts ...Option) *HTTPServer {
 	serve := &HTTPServer{
-		name:   defaultHTTPName,
-		logger: logger.DefaultExtended(),
+		name:     defaultHTTPName,
+		logger:   logger.DefaultExtended(),
+		inFlight: new(atomic.Int64),
 
 		Config: Config{Enabled: true},
 	}
@@ -43,6 +47,15 @@ func (s HTTPServer) Name() string { return s.name }
 // Enabled returns is service enabled.
 func (s HTTPServer) Enabled() bool { return s.Config.Enabled }
 
+// InFlight returns the number of requests being handled. It implements
+// mxtypes.InFlightReporter, so the launcher can wait for them while draining.
+func (s HTTPServer) InFlight() int64 {
+	if s.inFlight == nil {
+		return 0
+	}
+	return s.inFlight.Load()
+}
+
 // Start allows starting http server.
 func (s *HTTPServer) Start(ctx context.Context) error {
 	log := logger.WithExtended(
@@ -66,6 +79,9 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 	if !s.NoTrace {
 		handler = TracingMiddleware(s.handle)
 	}
+	if s.inFlight != nil {
+		handler = inFlightMiddleware(handler, s.inFlight)
+	}
 
 	if s.ReadTimeout == 0 {
 		s.ReadTimeout = 5
@@ -124,3 +140,12 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 
 	return nil
 }
+
+// inFlightMiddleware counts the requests being handled by next.
+func inFlightMiddleware(next http.Handler, counter *atomic.Int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter.Add(1)
+		defer counter.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}